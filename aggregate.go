@@ -0,0 +1,186 @@
+package ibtree
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// aggTask is one piece of an aggregation.  Either it covers the single item
+// held by n, or it covers every item in the subtree rooted at n that neither
+// lo nor hi return true for.  A nil lo or hi means that side is unbounded.
+type aggTask[T any] struct {
+	n      *node[T]
+	lo, hi Test[T]
+	single bool
+}
+
+// aggResult holds the reduced value of one aggTask.  ok is false when the
+// task did not cover any items.
+type aggResult[A any] struct {
+	a  A
+	ok bool
+}
+
+// splitAgg breaks the part of the subtree rooted at n that lies between lo and hi
+// into tasks, in order.  Subtrees no taller than grain are not broken up further.
+func splitAgg[T any](tasks []aggTask[T], n *node[T], lo, hi Test[T], grain uint64) []aggTask[T] {
+	for n != nil {
+		if n.h() <= grain {
+			return append(tasks, aggTask[T]{n: n, lo: lo, hi: hi})
+		}
+		switch {
+		case lo != nil && lo(n.i):
+			n = n.r
+		case hi != nil && hi(n.i):
+			n = n.l
+		default:
+			tasks = splitAgg(tasks, n.l, lo, nil, grain)
+			tasks = append(tasks, aggTask[T]{n: n, single: true})
+			n, lo = n.r, nil
+		}
+	}
+	return tasks
+}
+
+// aggregator carries the state shared by the workers of AggregateParallel.
+type aggregator[T, A any] struct {
+	ctx    context.Context
+	mapper func(T) A
+	reduce func(A, A) A
+	seen   int
+}
+
+func (g *aggregator[T, A]) add(res *aggResult[A], item T) {
+	v := g.mapper(item)
+	if res.ok {
+		res.a = g.reduce(res.a, v)
+	} else {
+		res.a, res.ok = v, true
+	}
+}
+
+// walk reduces the items in the subtree rooted at n that lie between lo and hi into res.
+// It returns false if the context was cancelled along the way.
+func (g *aggregator[T, A]) walk(res *aggResult[A], n *node[T], lo, hi Test[T]) bool {
+	for n != nil {
+		if g.seen++; g.seen&0x3ff == 0 && g.ctx.Err() != nil {
+			return false
+		}
+		switch {
+		case lo != nil && lo(n.i):
+			n = n.r
+		case hi != nil && hi(n.i):
+			n = n.l
+		default:
+			if !g.walk(res, n.l, lo, nil) {
+				return false
+			}
+			g.add(res, n.i)
+			n, lo = n.r, nil
+		}
+	}
+	return true
+}
+
+// AggregateParallel maps every item in t that lies between start and stop to an A
+// using mapper, and combines the results with reduce.  start and stop bound the
+// range the same way they do for Range, and either may be nil.
+//
+// The range is carved up into subtrees, which are handed out to up to workers
+// goroutines as they become idle.  If workers is less than 1, runtime.GOMAXPROCS(0)
+// is used instead.  mapper and reduce will be called concurrently, and reduce must be
+// associative.  Results are always combined in ascending item order, so reduce
+// does not need to be commutative.  If the range is empty, the zero value of A is returned.
+//
+// If ctx is cancelled before the aggregation finishes, AggregateParallel returns ctx.Err().
+func AggregateParallel[T, A any](ctx context.Context,
+	t *Tree[T],
+	start, stop Test[T],
+	mapper func(T) A,
+	reduce func(A, A) A,
+	workers int) (res A, err error) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	var grain uint64
+	if t.root != nil {
+		// Aim for about 8 tasks per worker so that idle workers have something to pick up.
+		grain = t.root.h()
+		for want := workers * 8; want > 1 && grain > 1; want >>= 1 {
+			grain--
+		}
+	}
+	tasks := splitAgg(nil, t.root, start, stop, grain)
+	results := make([]aggResult[A], len(tasks))
+	if workers > len(tasks) {
+		workers = len(tasks)
+	}
+	var next int64
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := &aggregator[T, A]{ctx: ctx, mapper: mapper, reduce: reduce}
+			for {
+				i := int(atomic.AddInt64(&next, 1) - 1)
+				if i >= len(tasks) || ctx.Err() != nil {
+					return
+				}
+				if task := tasks[i]; task.single {
+					g.add(&results[i], task.n.i)
+				} else if !g.walk(&results[i], task.n, task.lo, task.hi) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var acc aggResult[A]
+	for i := range results {
+		switch {
+		case !results[i].ok:
+		case acc.ok:
+			acc.a = reduce(acc.a, results[i].a)
+		default:
+			acc = results[i]
+		}
+	}
+	return acc.a, nil
+}
+
+// fold calls f on every item in the subtree rooted at n in ascending order.
+func fold[T, A any](acc A, n *node[T], f func(acc A, item T) A) A {
+	for n != nil {
+		acc = f(fold(acc, n.l, f), n.i)
+		n = n.r
+	}
+	return acc
+}
+
+// Reduce folds every item in t into init in ascending order by calling f with the running
+// result and each item in turn, and returns the final result.  It walks the nodes of t
+// directly instead of going through an Iter.
+func Reduce[T, A any](t *Tree[T], init A, f func(acc A, item T) A) A {
+	return fold(init, t.root, f)
+}
+
+// ParallelWalk calls fn once for every item in t, fanning the work out across up to workers
+// goroutines the same way AggregateParallel does.  fn will be called concurrently and in
+// no particular order.  If workers is less than 1, runtime.GOMAXPROCS(0) is used instead.
+// If ctx is cancelled before every item has been visited, ParallelWalk returns ctx.Err().
+func (t *Tree[T]) ParallelWalk(ctx context.Context, workers int, fn func(T)) error {
+	_, err := AggregateParallel(ctx, t, nil, nil,
+		func(item T) struct{} {
+			fn(item)
+			return struct{}{}
+		},
+		func(struct{}, struct{}) struct{} { return struct{}{} },
+		workers)
+	return err
+}
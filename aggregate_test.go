@@ -0,0 +1,96 @@
+package ibtree
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAggregateParallel(t *testing.T) {
+	src := rand.New(rand.NewSource(7))
+	tree := New[int](il, src.Perm(5000)...)
+	ctx := context.Background()
+	for _, workers := range []int{0, 1, 4, 16} {
+		for k := 0; k < 50; k++ {
+			lo, hi := src.Intn(5200)-100, src.Intn(5200)-100
+			var start, stop Test[int]
+			if k%5 != 0 {
+				start = Lt(tree.Cmp(lo))
+			}
+			if k%7 != 0 {
+				stop = Gt(tree.Cmp(hi))
+			}
+			expect := ""
+			tree.Range(start, stop, func(i int) bool {
+				expect += strconv.Itoa(i) + ","
+				return true
+			})
+			got, err := AggregateParallel[int, string](ctx, tree, start, stop,
+				func(i int) string { return strconv.Itoa(i) + "," },
+				func(a, b string) string { return a + b },
+				workers)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if got != expect {
+				t.Fatalf("workers %d, %d..%d: expected %q, got %q", workers, lo, hi, expect, got)
+			}
+		}
+	}
+	sum, err := AggregateParallel[int, int](ctx, New[int](il), nil, nil,
+		func(i int) int { return i },
+		func(a, b int) int { return a + b }, 4)
+	if err != nil || sum != 0 {
+		t.Fatalf("Expected 0 from an empty tree, got %d, %v", sum, err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = AggregateParallel[int, int](cancelled, tree, nil, nil,
+		func(i int) int { return i },
+		func(a, b int) int { return a + b }, 4); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	tree := New[int](il, rand.Perm(100)...)
+	if sum := Reduce(tree, 0, func(acc, i int) int { return acc + i }); sum != 4950 {
+		t.Fatalf("Expected 4950, got %d", sum)
+	}
+	order := Reduce(tree, []int{}, func(acc []int, i int) []int { return append(acc, i) })
+	for i := range order {
+		if len(order) != 100 || order[i] != i {
+			t.Fatalf("Reduce visited items out of order: %v", order)
+		}
+	}
+	if v := Reduce(New[int](il), "empty", func(acc string, i int) string { return "" }); v != "empty" {
+		t.Fatalf("Reducing an empty Tree should return init")
+	}
+}
+
+func TestParallelWalk(t *testing.T) {
+	tree := New[int](il, rand.Perm(10000)...)
+	for _, workers := range []int{0, 1, 4} {
+		var sum int64
+		seen := make([]int32, 10000)
+		err := tree.ParallelWalk(context.Background(), workers, func(i int) {
+			atomic.AddInt64(&sum, int64(i))
+			atomic.AddInt32(&seen[i], 1)
+		})
+		if err != nil || sum != 49995000 {
+			t.Fatalf("workers %d: expected sum 49995000, got %d %v", workers, sum, err)
+		}
+		for i := range seen {
+			if seen[i] != 1 {
+				t.Fatalf("workers %d: item %d visited %d times", workers, i, seen[i])
+			}
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := tree.ParallelWalk(ctx, 2, func(int) {}); err == nil {
+		t.Fatalf("Expected an error from a cancelled context")
+	}
+}
@@ -0,0 +1,81 @@
+package ibtree
+
+// Balancer is the policy a Tree uses to decide when to rebalance itself.
+// Looser policies allow the Tree to grow deeper in exchange for performing fewer
+// rotations (and therefore copying fewer nodes) as items are inserted and deleted.
+//
+// Balancers are created with StrictAVL, RelaxedAVL, or Scapegoat, and applied to a Tree with the
+// Balancing Option.
+type Balancer interface {
+	// skew returns the largest height difference allowed between the two children of a node.
+	skew() int
+	// rebuilds returns true if subtrees that are out of balance should be rebuilt
+	// from scratch instead of being rotated back into balance.
+	rebuilds() bool
+}
+
+type avlBalancer int
+
+func (a avlBalancer) skew() int      { return int(a) }
+func (a avlBalancer) rebuilds() bool { return false }
+
+type scapegoatBalancer int
+
+func (s scapegoatBalancer) skew() int      { return int(s) }
+func (s scapegoatBalancer) rebuilds() bool { return true }
+
+func clampSlack(slack int) int {
+	if slack < 0 {
+		return 0
+	} else if slack > maxSlack {
+		return maxSlack
+	}
+	return slack
+}
+
+// maxSlack keeps the worst case height of a relaxed Tree with 2^64 items below
+// the 255 levels that can be recorded in a node.
+const maxSlack = 7
+
+// StrictAVL returns the default Balancer, which keeps the Tree a strict AVL tree:
+// the heights of the children of every node differ by at most one.
+func StrictAVL() Balancer {
+	return avlBalancer(1)
+}
+
+// RelaxedAVL returns a Balancer that allows the heights of the children of a node to differ
+// by up to 1+slack before the Tree rebalances itself.  A slack of 0 is the same as StrictAVL.
+// slack is clamped to between 0 and 7.  The worst case height of the Tree grows with slack:
+// a slack of 1 makes it about 25% greater than with StrictAVL, and a slack of 7 makes
+// it about 2.5 times greater.
+func RelaxedAVL(slack int) Balancer {
+	return avlBalancer(1 + clampSlack(slack))
+}
+
+// Scapegoat returns a Balancer tuned for heavy insert and delete churn.  Like RelaxedAVL,
+// it allows the heights of the children of a node to differ by up to 1+slack.  Unlike RelaxedAVL,
+// it never rotates.  When a subtree gets too far out of balance, the whole subtree is rebuilt
+// into a perfectly balanced one in a single pass.  That is more expensive than a rotation, but
+// it happens much less often, and the rebuilt subtree can absorb a lot more churn before it has
+// to be rebuilt again.  slack is clamped to between 0 and 7.
+func Scapegoat(slack int) Balancer {
+	return scapegoatBalancer(1 + clampSlack(slack))
+}
+
+// Balancing sets the Balancer the Tree will use.
+func Balancing(b Balancer) Option {
+	return func(o *options) {
+		o.balancer = b
+	}
+}
+
+func (o *options) skew() int {
+	if o.balancer == nil {
+		return 1
+	}
+	return o.balancer.skew()
+}
+
+func (o *options) rebuilds() bool {
+	return o.balancer != nil && o.balancer.rebuilds()
+}
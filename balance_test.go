@@ -0,0 +1,98 @@
+package ibtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRelaxedAVL(t *testing.T) {
+	src := rand.New(rand.NewSource(9))
+	for _, slack := range []int{-1, 0, 1, 2, 5, 100} {
+		b := RelaxedAVL(slack)
+		skew := b.skew()
+		tree := NewWith[int](il, Balancing(b))
+		seq := NewWith[int](il, Balancing(b))
+		present := map[int]bool{}
+		for i := 0; i < 5000; i++ {
+			v := src.Intn(2000)
+			if src.Intn(3) == 0 {
+				var found bool
+				tree, _, found = tree.Delete(v)
+				if found != present[v] {
+					t.Fatalf("slack %d: delete %d found %v, expected %v", slack, v, found, present[v])
+				}
+				delete(present, v)
+			} else {
+				tree = tree.Insert(v)
+				present[v] = true
+			}
+			seq = seq.Insert(i)
+		}
+		tree.root.balancedWithin(t, skew)
+		seq.root.balancedWithin(t, skew)
+		if tree.Len() != len(present) {
+			t.Fatalf("slack %d: expected %d items, got %d", slack, len(present), tree.Len())
+		}
+		last := -1
+		tree.Walk(func(i int) bool {
+			if i <= last || !present[i] {
+				t.Fatalf("slack %d: bad item %d after %d", slack, i, last)
+			}
+			last = i
+			return true
+		})
+		for i := 0; i < 5000; i++ {
+			seq, _, _ = seq.Delete(i)
+			if i%500 == 0 {
+				seq.root.balancedWithin(t, skew)
+			}
+		}
+		if seq.Len() != 0 {
+			t.Fatalf("slack %d: %d items left after deleting everything", slack, seq.Len())
+		}
+	}
+	if StrictAVL().skew() != 1 || RelaxedAVL(0).skew() != 1 {
+		t.Fatalf("RelaxedAVL(0) should be StrictAVL")
+	}
+}
+
+func TestScapegoat(t *testing.T) {
+	src := rand.New(rand.NewSource(10))
+	for _, slack := range []int{0, 2, 7} {
+		b := Scapegoat(slack)
+		tree := NewWith[int](il, Balancing(b))
+		present := map[int]bool{}
+		for round := 0; round < 20; round++ {
+			tree = tree.InsertWith(func(f func(int)) {
+				for i := 0; i < 500; i++ {
+					v := src.Intn(1000)
+					f(v)
+					present[v] = true
+				}
+			})
+			old := tree
+			tree = tree.DeleteWith(func(f func(int) (int, bool)) {
+				for i := 0; i < 500; i++ {
+					v := src.Intn(1000)
+					if _, found := f(v); found != present[v] {
+						t.Fatalf("slack %d: delete %d found %v, expected %v", slack, v, found, present[v])
+					}
+					delete(present, v)
+				}
+			})
+			old.root.balancedWithin(t, b.skew())
+			tree.root.balancedWithin(t, b.skew())
+			if tree.Len() != len(present) {
+				t.Fatalf("slack %d: expected %d items, got %d", slack, len(present), tree.Len())
+			}
+		}
+		last := -1
+		tree.Walk(func(i int) bool {
+			if i <= last || !present[i] {
+				t.Fatalf("slack %d: bad item %d after %d", slack, i, last)
+			}
+			last = i
+			return true
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package ibtree
 
-import "sync"
+import (
+	"errors"
+	"sync"
+)
 
 const (
 	leftHeavy  = -2
@@ -46,51 +49,114 @@ type Tree[T any] struct {
 	less  LessThan[T]
 	gen   uint64
 	count int
+	// deleted counts the items deleted since the Tree was last rebuilt.
+	deleted int
+	opts    options
+	guard   writeGuard
 }
 
 func (t *Tree[T]) getNsp() *nodeStack[T] {
-	res := t.nsp.Get().(*nodeStack[T])
+	var res *nodeStack[T]
+	if t.nsp == nil {
+		res = &nodeStack[T]{}
+	} else {
+		res = t.nsp.Get().(*nodeStack[T])
+	}
+	// An insert can add at most one level to the path from the root.
+	if need := height(t.root) + 2; cap(res.s) < need {
+		res.s = make([]*node[T], 0, need)
+	}
+	res.guard.acquire()
 	res.gen = t.gen
+	res.skew = t.opts.skew()
+	res.rebuild = t.opts.rebuilds()
 	return res
 }
 
 func (t *Tree[T]) putNsp(n *nodeStack[T]) {
+	// Clear the whole backing array, since the stack may have been longer earlier on.
+	n.s = n.s[:cap(n.s)]
 	for i := range n.s {
 		n.s[i] = nil
 	}
 	n.s = n.s[:0]
+	n.guard.release()
+	if t.nsp != nil {
+		t.nsp.Put(n)
+	}
 }
 
-func (t *Tree[T]) insertOne(ins *nodeStack[T], item T) {
+// insertOne inserts item into t, and returns the item it replaced and whether there was one.
+func (t *Tree[T]) insertOne(ins *nodeStack[T], item T) (old T, replaced bool) {
+	defer t.enterWrite(ins)()
 	if t.root == nil {
 		t.root = ins.newNode(item)
 		t.count = 1
 		return
 	}
-	direction := t.getExact(ins, t.root, item)
+	var direction int
+	if t.opts.stable {
+		direction = t.getAfter(ins, t.root, item)
+	} else {
+		direction = t.getExact(ins, t.root, item)
+	}
+	if direction == Equal {
+		old, replaced = ins.at(-1).i, true
+	}
+	t.attach(ins, direction, item)
+	return
+}
+
+// attach puts item where getExact or getAfter said it should go, either in place of
+// the item at the top of ins or in a new child of it.  ins must hold a copied path.
+func (t *Tree[T]) attach(ins *nodeStack[T], direction int, item T) {
 	n := ins.at(-1)
-	needRebalance := false
 	if direction == Equal {
 		n.i = item
 	} else {
 		t.count++
 		if direction == Less {
 			n.l = ins.newNode(item)
-			needRebalance = n.r == nil
 		} else {
 			n.r = ins.newNode(item)
-			needRebalance = n.l == nil
 		}
-	}
-	if needRebalance {
 		rebalance(ins)
 	}
 	t.root = ins.at(0)
 }
 
+// upsertOne inserts item into t if there is no equal item in it already.  If there is, merge
+// is called with the existing item and item, and the item it returns replaces the existing one
+// unless it also returns false.  Nodes are only copied if t actually changes.  upsertOne returns
+// the existing item and whether there was one.
+func (t *Tree[T]) upsertOne(ins *nodeStack[T], item T, merge func(old, new T) (T, bool)) (old T, found bool) {
+	defer t.enterWrite(ins)()
+	if t.root == nil {
+		t.root = ins.newNode(item)
+		t.count = 1
+		return
+	}
+	direction := t.findExact(ins, t.root, item)
+	if direction == Equal {
+		old, found = ins.at(-1).i, true
+		var store bool
+		if item, store = merge(old, item); !store {
+			return
+		}
+	}
+	ins.copyPath()
+	t.attach(ins, direction, item)
+	return
+}
+
+// keepOld is a merge function for upsertOne that leaves existing items alone.
+func keepOld[T any](old, _ T) (T, bool) {
+	return old, false
+}
+
 // New allocates a new Tree that will keep itself ordered according to the passed in LessThan.
 func New[T any](lt LessThan[T], items ...T) *Tree[T] {
-	res := &Tree[T]{less: lt, nsp: &sync.Pool{New: func() any { return &nodeStack[T]{} }}}
+	res := NewWith[T](lt)
 	if len(items) > 0 {
 		ins := res.getNsp()
 		defer res.putNsp(ins)
@@ -101,6 +167,23 @@ func New[T any](lt LessThan[T], items ...T) *Tree[T] {
 	return res
 }
 
+// Comparer is implemented by types that know how to order themselves.
+// Compare must return a negative number if the receiver sorts before other,
+// zero if the two are equal, and a positive number if the receiver sorts after other.
+type Comparer[T any] interface {
+	Compare(other T) int
+}
+
+func compareLess[T Comparer[T]](a, b T) bool {
+	return a.Compare(b) < 0
+}
+
+// NewComparable allocates a new Tree that will keep itself ordered according to the
+// Compare method of its items.
+func NewComparable[T Comparer[T]](items ...T) *Tree[T] {
+	return New[T](compareLess[T], items...)
+}
+
 // Fill is a function that is passed another function that can insert
 // a single item into a Tree.  It is used by CreateWith and InsertWith to
 // amortize costs associated with copy-on-write when performing bulk insert
@@ -122,7 +205,7 @@ func CreateWith[T any](lt LessThan[T], fill Fill[T]) *Tree[T] {
 
 // Bud creates a new Tree with the passed-in items
 func (t *Tree[T]) Bud(lt LessThan[T], items ...T) *Tree[T] {
-	res := &Tree[T]{less: lt, nsp: t.nsp}
+	res := &Tree[T]{less: lt, nsp: newPool[T](t.opts, t.nsp), opts: t.opts}
 	if len(items) > 0 {
 		ins := res.getNsp()
 		defer res.putNsp(ins)
@@ -157,7 +240,7 @@ func copyNodes[T any](n *node[T], reverse bool) *node[T] {
 	if n == nil {
 		return nil
 	}
-	res := &node[T]{genH: n.h(), i: n.i}
+	res := &node[T]{genH: n.h(), i: n.i, size: n.size}
 	if n.l != nil {
 		res.r = copyNodes(n.l, reverse)
 	}
@@ -173,7 +256,7 @@ func copyNodes[T any](n *node[T], reverse bool) *node[T] {
 // Fork makes a new copy of the Tree that has the same ordering function and data.
 // It will share nodes with the original Tree.
 func (t *Tree[T]) Fork() *Tree[T] {
-	res := &Tree[T]{less: t.less, root: t.root, count: t.count, nsp: t.nsp, gen: t.gen + 1}
+	res := &Tree[T]{less: t.less, root: t.root, count: t.count, deleted: t.deleted, nsp: newPool[T](t.opts, t.nsp), gen: t.gen + 1, opts: t.opts}
 	if res.gen < maxGen {
 		return res
 	}
@@ -193,10 +276,11 @@ func (t *Tree[T]) Fork() *Tree[T] {
 func (t *Tree[T]) Reverse() *Tree[T] {
 	ll := t.less
 	return &Tree[T]{
-		nsp:   t.nsp,
+		nsp:   newPool[T](t.opts, t.nsp),
 		less:  func(a, b T) bool { return ll(b, a) },
 		count: t.count,
 		root:  copyNodes(t.root, true),
+		opts:  t.opts,
 	}
 }
 
@@ -207,7 +291,8 @@ func (t *Tree[T]) Reverse() *Tree[T] {
 func (t *Tree[T]) SortBy(l LessThan[T]) *Tree[T] {
 	prevLess := t.less
 	return &Tree[T]{
-		nsp: t.nsp,
+		nsp:  newPool[T](t.opts, t.nsp),
+		opts: t.opts,
 		less: func(a, b T) bool {
 			switch {
 			case l(a, b):
@@ -221,11 +306,34 @@ func (t *Tree[T]) SortBy(l LessThan[T]) *Tree[T] {
 	}
 }
 
+// cloneNodes is copyNodes for DeepClone.  It keeps the shape of the subtree rooted
+// at n, and passes every item through clone if it is not nil.
+func cloneNodes[T any](n *node[T], clone func(T) T) *node[T] {
+	if n == nil {
+		return nil
+	}
+	res := &node[T]{genH: n.h(), i: n.i, size: n.size, l: cloneNodes(n.l, clone)}
+	if clone != nil {
+		res.i = clone(n.i)
+	}
+	res.r = cloneNodes(n.r, clone)
+	return res
+}
+
+// DeepClone returns a copy of t that does not share any nodes with t or any other Tree.
+// If clone is not nil, it is called on every item in ascending order, and the items it returns
+// are stored in the copy instead.  clone must not change the ordering of the items.
+// Use DeepClone to hand a Tree across an isolation boundary without pinning other versions of it.
+func (t *Tree[T]) DeepClone(clone func(T) T) *Tree[T] {
+	return &Tree[T]{less: t.less, nsp: newPool[T](t.opts, t.nsp), opts: t.opts, root: cloneNodes(t.root, clone), count: t.count}
+}
+
 // SortedClone makes a new Tree using SortBy, then inserts all the data from t into it.
 func (t *Tree[T]) SortedClone(l LessThan[T]) *Tree[T] {
 	res := t.SortBy(l)
 	iter := t.All()
-	ins := &nodeStack[T]{}
+	ins := res.getNsp()
+	defer res.putNsp(ins)
 	for iter.Next() {
 		res.insertOne(ins, iter.Item())
 	}
@@ -244,7 +352,9 @@ const unorderable = `Unorderable CompareAgainst passed to Get`
 // the items matching CompareAgainst, use one of the Range, Before, or After instead.
 func (t *Tree[T]) Get(cmp CompareAgainst[T]) (item T, found bool) {
 	h := t.root
+	depth := 0
 	for h != nil {
+		depth++
 		switch cmp(h.i) {
 		case Greater:
 			h = h.l
@@ -252,11 +362,13 @@ func (t *Tree[T]) Get(cmp CompareAgainst[T]) (item T, found bool) {
 			h = h.r
 		case Equal:
 			item, found = h.i, true
+			t.opts.lookups.record(depth, true)
 			return
 		default:
 			panic(unorderable)
 		}
 	}
+	t.opts.lookups.record(depth, false)
 	return
 }
 
@@ -270,17 +382,157 @@ func (t *Tree[T]) Has(cmp CompareAgainst[T]) bool {
 // or the zero value for T, false if it is not.
 func (t *Tree[T]) Fetch(item T) (v T, found bool) {
 	n := t.root
+	depth := 0
 	for n != nil {
+		depth++
 		if t.less(item, n.i) {
 			n = n.l
 		} else if t.less(n.i, item) {
 			n = n.r
 		} else {
-			found = true
-			v = n.i
+			t.opts.lookups.record(depth, true)
 			return n.i, true
 		}
 	}
+	t.opts.lookups.record(depth, false)
+	return
+}
+
+// HasItem returns true if the Tree contains an item equal to item.
+// Unlike Has, it does not need a CompareAgainst.
+func (t *Tree[T]) HasItem(item T) bool {
+	_, found := t.Fetch(item)
+	return found
+}
+
+// GetKey is Get for callers that want to look items up by a key of some other type without
+// building a CompareAgainst closure for every lookup.  cmp must compare an item in the Tree to key
+// the same way a CompareAgainst would, and can be an ordinary function or method value that
+// is created once and reused.
+func GetKey[T, K any](t *Tree[T], key K, cmp func(item T, key K) int) (item T, found bool) {
+	h := t.root
+	depth := 0
+	for h != nil {
+		depth++
+		switch cmp(h.i, key) {
+		case Greater:
+			h = h.l
+		case Less:
+			h = h.r
+		case Equal:
+			item, found = h.i, true
+			t.opts.lookups.record(depth, true)
+			return
+		default:
+			panic(unorderable)
+		}
+	}
+	t.opts.lookups.record(depth, false)
+	return
+}
+
+// HasKey returns true if the Tree contains an item equal to key according to cmp.
+// See GetKey for details.
+func HasKey[T, K any](t *Tree[T], key K, cmp func(item T, key K) int) bool {
+	_, found := GetKey(t, key, cmp)
+	return found
+}
+
+// GetOr returns the highest item in the Tree that is equal to CompareAgainst,
+// or def if there is no such item.
+func (t *Tree[T]) GetOr(cmp CompareAgainst[T], def T) T {
+	if item, found := t.Get(cmp); found {
+		return item
+	}
+	return def
+}
+
+// FetchOr returns the exact match for item if it is in the Tree, or def if it is not.
+func (t *Tree[T]) FetchOr(item, def T) T {
+	if v, found := t.Fetch(item); found {
+		return v
+	}
+	return def
+}
+
+// lastWhile returns the last item in the subtree rooted at n that test returns true for.
+// test must return true for all the items up to some point, and false for the rest.
+func lastWhile[T any](n *node[T], test Test[T]) (item T, found bool) {
+	for n != nil {
+		if test(n.i) {
+			item, found = n.i, true
+			n = n.r
+		} else {
+			n = n.l
+		}
+	}
+	return
+}
+
+// firstAfter returns the first item in the subtree rooted at n that test returns false for.
+// test must return true for all the items up to some point, and false for the rest.
+func firstAfter[T any](n *node[T], test Test[T]) (item T, found bool) {
+	for n != nil {
+		if test(n.i) {
+			n = n.r
+		} else {
+			item, found = n.i, true
+			n = n.l
+		}
+	}
+	return
+}
+
+// Floor returns the largest item in the Tree that is less than or equal to item and true,
+// or a zero T and false if there is no such item.
+func (t *Tree[T]) Floor(item T) (T, bool) {
+	return lastWhile(t.root, func(v T) bool { return !t.less(item, v) })
+}
+
+// Ceiling returns the smallest item in the Tree that is greater than or equal to item and true,
+// or a zero T and false if there is no such item.
+func (t *Tree[T]) Ceiling(item T) (T, bool) {
+	return firstAfter(t.root, func(v T) bool { return t.less(v, item) })
+}
+
+// Successor returns the smallest item in the Tree that is greater than item and true,
+// or a zero T and false if there is no such item.  item does not need to be in the Tree.
+func (t *Tree[T]) Successor(item T) (T, bool) {
+	return firstAfter(t.root, func(v T) bool { return !t.less(item, v) })
+}
+
+// Predecessor returns the largest item in the Tree that is less than item and true,
+// or a zero T and false if there is no such item.  item does not need to be in the Tree.
+func (t *Tree[T]) Predecessor(item T) (T, bool) {
+	return lastWhile(t.root, func(v T) bool { return t.less(v, item) })
+}
+
+// Neighbors finds item in the Tree in a single descent.  exact is the item in the Tree equal
+// to item, prev is the largest item less than item, and next is the smallest item greater
+// than item.  Each is returned with whether it was found.
+func (t *Tree[T]) Neighbors(item T) (prev, exact, next T, hasPrev, hasExact, hasNext bool) {
+	n := t.root
+	for n != nil {
+		if t.less(item, n.i) {
+			next, hasNext = n.i, true
+			n = n.l
+		} else if t.less(n.i, item) {
+			prev, hasPrev = n.i, true
+			n = n.r
+		} else {
+			break
+		}
+	}
+	if n == nil {
+		return
+	}
+	exact, hasExact = n.i, true
+	if v, ok := lastWhile(n.l, func(v T) bool { return t.less(v, item) }); ok {
+		prev, hasPrev = v, true
+	}
+	if v, ok := firstAfter(n.r, func(v T) bool { return !t.less(item, v) }); ok {
+		next, hasNext = v, true
+	}
 	return
 }
 
@@ -312,6 +564,7 @@ func (t *Tree[T]) InsertWith(fill Fill[T]) *Tree[T] {
 		res.insertOne(ins, v)
 	}
 	fill(thunk)
+	res.compactIfNeeded(ins)
 	return res
 }
 
@@ -324,6 +577,7 @@ func (t *Tree[T]) InsertFrom(src Iter[T]) *Tree[T] {
 	for src.Next() {
 		res.insertOne(ins, src.Item())
 	}
+	res.compactIfNeeded(ins)
 	return res
 }
 
@@ -336,17 +590,108 @@ func (t *Tree[T]) Insert(item ...T) *Tree[T] {
 	for i := range item {
 		res.insertOne(ins, item[i])
 	}
+	res.compactIfNeeded(ins)
+	return res
+}
+
+// GetOrInsert returns the item in the Tree equal to item if there is one, along with t itself
+// and false.  Otherwise, it returns a new Tree with item inserted, along with item and true.
+// Only one descent is made, and no nodes are copied unless item is inserted.
+// If t was created with StableTies, the existing item is whichever equal one is found first.
+func (t *Tree[T]) GetOrInsert(item T) (into *Tree[T], existing T, inserted bool) {
+	into = t.Fork()
+	ins := into.getNsp()
+	defer into.putNsp(ins)
+	if existing, found := into.upsertOne(ins, item, keepOld[T]); found {
+		return t, existing, false
+	}
+	return into, item, true
+}
+
+// InsertReturning is Insert for a single item that also returns the item it replaced
+// and true, or a zero T and false if the Tree did not hold an item equal to item.
+func (t *Tree[T]) InsertReturning(item T) (into *Tree[T], old T, replaced bool) {
+	into = t.Fork()
+	ins := into.getNsp()
+	defer into.putNsp(ins)
+	old, replaced = into.insertOne(ins, item)
+	return
+}
+
+// InsertReplacing returns a new Tree with items inserted, along with the items they
+// replaced in the order they were replaced.  If items holds several equal items, every
+// one but the last is also returned, since each replaces the one before it.
+func (t *Tree[T]) InsertReplacing(items ...T) (into *Tree[T], replaced []T) {
+	into = t.Fork()
+	ins := into.getNsp()
+	defer into.putNsp(ins)
+	for i := range items {
+		if old, found := into.insertOne(ins, items[i]); found {
+			replaced = append(replaced, old)
+		}
+	}
+	into.compactIfNeeded(ins)
+	return
+}
+
+// InsertNoReplace returns a new Tree with each of items inserted unless the Tree already holds
+// an item equal to it, along with the number of items that were inserted.  Existing items are
+// never replaced, which gives first-writer-wins semantics.  This also holds for Trees created
+// with StableTies, which will not get a second equal item.
+func (t *Tree[T]) InsertNoReplace(items ...T) (into *Tree[T], inserted int) {
+	into = t.Fork()
+	ins := into.getNsp()
+	defer into.putNsp(ins)
+	for i := range items {
+		if _, found := into.upsertOne(ins, items[i], keepOld[T]); !found {
+			inserted++
+		}
+	}
+	into.compactIfNeeded(ins)
+	return
+}
+
+// Upsert returns a new Tree with item inserted.  If the Tree already holds an item equal to
+// item, merge is called with the existing item and item, and the item it returns is stored
+// instead.  The returned item must be equal to the ones passed in.
+func (t *Tree[T]) Upsert(item T, merge func(old, new T) T) *Tree[T] {
+	return t.UpsertWith(func(add func(T)) { add(item) }, merge)
+}
+
+// UpsertWith is Upsert for every item returned by fill.  All the items are
+// added to a single new Tree.
+func (t *Tree[T]) UpsertWith(fill Fill[T], merge func(old, new T) T) *Tree[T] {
+	res := t.Fork()
+	ins := res.getNsp()
+	defer res.putNsp(ins)
+	resolve := func(old, new T) (T, bool) { return merge(old, new), true }
+	fill(func(v T) {
+		res.upsertOne(ins, v, resolve)
+	})
+	res.compactIfNeeded(ins)
 	return res
 }
 
 func (into *Tree[T]) deleteOne(ins *nodeStack[T], item T) (deleted T, found bool) {
+	defer into.enterWrite(ins)()
 	if into.root == nil {
 		return
 	}
-	direction := into.getExact(ins, into.root, item)
+	var direction int
+	if into.opts.stable {
+		direction = into.getFirst(ins, into.root, item)
+	} else {
+		direction = into.getExact(ins, into.root, item)
+	}
 	if found = direction == Equal; !found {
 		return
 	}
+	return into.removeTop(ins), true
+}
+
+// removeTop removes the node at the top of ins from the Tree and returns its item.
+// ins must hold the path from the root of the Tree to the node.
+func (into *Tree[T]) removeTop(ins *nodeStack[T]) (deleted T) {
 	at := ins.at(-1)
 	deleted = at.i
 	var alt *node[T]
@@ -367,6 +712,7 @@ func (into *Tree[T]) deleteOne(ins *nodeStack[T], item T) (deleted T, found bool
 				into.gen = 0
 			}
 			into.count--
+			into.deleted++
 			return
 		} else if at.r != nil {
 			at.getLeftmost(ins)
@@ -392,6 +738,135 @@ func (t *Tree[T]) Delete(item T) (into *Tree[T], deleted T, found bool) {
 	return
 }
 
+// pop removes the smallest item from the Tree if smallest is true, or the largest if it is not.
+func (t *Tree[T]) pop(smallest bool) (into *Tree[T], item T, found bool) {
+	if t.root == nil {
+		return t, item, false
+	}
+	into = t.Fork()
+	ins := into.getNsp()
+	defer into.putNsp(ins)
+	defer into.enterWrite(ins)()
+	ins.clear()
+	ins.add(into.root)
+	for n := into.root; ; {
+		if smallest && n.l != nil {
+			ins.addLeft(n.l)
+			n = n.l
+		} else if !smallest && n.r != nil {
+			ins.addRight(n.r)
+			n = n.r
+		} else {
+			break
+		}
+	}
+	return into, into.removeTop(ins), true
+}
+
+// PopMin returns a new Tree with the smallest item removed, along with that item and true.
+// If the Tree is empty, PopMin returns t, a zero T, and false.  Only one descent is made, and
+// the new Tree shares nodes with t just as it would with Delete.
+func (t *Tree[T]) PopMin() (into *Tree[T], item T, found bool) {
+	return t.pop(true)
+}
+
+// PopMax is PopMin for the largest item in the Tree.
+func (t *Tree[T]) PopMax() (into *Tree[T], item T, found bool) {
+	return t.pop(false)
+}
+
+// ErrKeyChanged is returned by UpdateItem when the updated item no longer compares
+// equal to the one it was supposed to replace.
+var ErrKeyChanged = errors.New("ibtree: updated item is not equal to the original")
+
+// UpdateItem returns a new Tree in which the item equal to cmp has been replaced
+// by the result of calling fn on it, along with true.  If there is no such item,
+// t and false are returned.  Only the nodes on the path to the item are copied,
+// and the Tree is not rebalanced.
+//
+// fn must not change the parts of the item that determine its position in the Tree.
+// If the updated item does not compare equal to the original one, UpdateItem returns
+// t, true, and ErrKeyChanged, and the updated item is thrown away.
+func (t *Tree[T]) UpdateItem(cmp CompareAgainst[T], fn func(T) T) (into *Tree[T], found bool, err error) {
+	res := t.Fork()
+	ins := res.getNsp()
+	defer res.putNsp(ins)
+	defer res.enterWrite(ins)()
+	n := res.root
+	if n == nil {
+		return t, false, nil
+	}
+	ins.add(n)
+	for {
+		switch cmp(n.i) {
+		case Greater:
+			if n.l == nil {
+				return t, false, nil
+			}
+			ins.addLeft(n.l)
+			n = n.l
+		case Less:
+			if n.r == nil {
+				return t, false, nil
+			}
+			ins.addRight(n.r)
+			n = n.r
+		case Equal:
+			n = ins.at(-1)
+			updated := fn(n.i)
+			if t.less(updated, n.i) || t.less(n.i, updated) {
+				return t, true, ErrKeyChanged
+			}
+			n.i = updated
+			res.root = ins.at(0)
+			return res, true, nil
+		default:
+			panic(unorderable)
+		}
+	}
+}
+
+// Update looks up the item equal to cmp and calls fn with it and true, or with a zero T and
+// false if there is no such item.  If fn returns true, the item it returns is stored in a new
+// Tree, either replacing the existing item or being inserted where it would have been.  If fn
+// returns false, the existing item is deleted, or nothing happens if there was none.  All of
+// this happens in a single descent, and nodes are only copied if the Tree changes.  If nothing
+// changes, t itself is returned.  found is whether there was an item equal to cmp.
+//
+// The item fn stores must be equal to cmp.  If it is not, Update returns t, found, and
+// ErrKeyChanged, and the item is thrown away, just as UpdateItem does.
+func (t *Tree[T]) Update(cmp CompareAgainst[T], fn func(old T, found bool) (T, bool)) (into *Tree[T], found bool, err error) {
+	res := t.Fork()
+	ins := res.getNsp()
+	defer res.putNsp(ins)
+	defer res.enterWrite(ins)()
+	var old T
+	direction := Less
+	if res.root != nil {
+		if direction = res.findCmp(ins, res.root, cmp); direction == Equal {
+			old = ins.at(-1).i
+		}
+	}
+	found = direction == Equal
+	item, store := fn(old, found)
+	switch {
+	case store && cmp(item) != Equal:
+		return t, found, ErrKeyChanged
+	case store && res.root == nil:
+		res.root = ins.newNode(item)
+		res.count = 1
+	case store:
+		ins.copyPath()
+		res.attach(ins, direction, item)
+	case found:
+		ins.copyPath()
+		res.removeTop(ins)
+	default:
+		return t, false, nil
+	}
+	return res, found, nil
+}
+
 // Erase is a function signature that can be used to bulk delete items from
 // a Tree.  The inner function expects a T to be removed from the Tree, and returns
 // the value removed and whether the value was found.
@@ -406,6 +881,7 @@ func (t *Tree[T]) DeleteWith(erase Erase[T]) *Tree[T] {
 		return
 	}
 	erase(thunk)
+	res.compactIfNeeded(ins)
 	return res
 }
 
@@ -418,6 +894,7 @@ func (t *Tree[T]) DeleteFrom(src Iter[T]) *Tree[T] {
 	for src.Next() {
 		res.deleteOne(ins, src.Item())
 	}
+	res.compactIfNeeded(ins)
 	return res
 }
 
@@ -433,5 +910,6 @@ func (t *Tree[T]) DeleteItems(items ...T) (into *Tree[T], deleted int) {
 			deleted++
 		}
 	}
+	into.compactIfNeeded(ins)
 	return
 }
@@ -2,6 +2,7 @@ package ibtree
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -19,9 +20,21 @@ func (n *node[T]) height() uint64 {
 	return n.h()
 }
 
+func (n *node[T]) countSize() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
 // balanced checks a Tree to ensure it is AVL compliant.
 // Only for use when running tests.
 func (n *node[T]) balanced(t *testing.T) {
+	n.balancedWithin(t, 1)
+}
+
+// balancedWithin checks that the heights of sibling subtrees differ by no more than skew.
+func (n *node[T]) balancedWithin(t *testing.T, skew int) {
 	if n == nil {
 		return
 	}
@@ -41,21 +54,24 @@ func (n *node[T]) balanced(t *testing.T) {
 	if !(n.h()-lh == 1 || n.h()-rh == 1) {
 		panic("Height not max(lh,rh)+1")
 	}
+	if n.size != 1+n.l.countSize()+n.r.countSize() {
+		panic("Subtree size is wrong")
+	}
 	b := n.balance()
 	rb := int(rh) - int(lh)
 	if b != rb {
 		panic("Balance calculated incorrectly")
 	}
-	if b > 1 {
+	if b > skew {
 		panic("Too heavy to the right!")
-	} else if b < -1 {
+	} else if b < -skew {
 		panic("Too heavy to the left!")
 	}
 	if n.l != nil {
-		n.l.balanced(t)
+		n.l.balancedWithin(t, skew)
 	}
 	if n.r != nil {
-		n.r.balanced(t)
+		n.r.balancedWithin(t, skew)
 	}
 }
 
@@ -893,3 +909,867 @@ func TestFetch(t *testing.T) {
 		}
 	}
 }
+
+type cmpInt int
+
+func (c cmpInt) Compare(other cmpInt) int { return int(c) - int(other) }
+
+func TestNewComparable(t *testing.T) {
+	tree := NewComparable[cmpInt](5, 3, 9, 1, 3, 7)
+	tree.root.balanced(t)
+	expect := []cmpInt{1, 3, 5, 7, 9}
+	res := []cmpInt{}
+	tree.Walk(func(c cmpInt) bool {
+		res = append(res, c)
+		return true
+	})
+	if !reflect.DeepEqual(expect, res) {
+		t.Fatalf("Expected %v, got %v", expect, res)
+	}
+	if !tree.Has(tree.Cmp(7)) || tree.Has(tree.Cmp(4)) {
+		t.Fatalf("Lookups failed")
+	}
+}
+
+func TestGetOrFetchOr(t *testing.T) {
+	tree := New[ovr](ol, ovr{1, 1}, ovr{2, 2})
+	def := ovr{-1, -1}
+	if v := tree.GetOr(tree.Cmp(ovr{i: 2}), def); v.mark != 2 {
+		t.Fatalf("Expected mark 2, got %v", v)
+	}
+	if v := tree.GetOr(tree.Cmp(ovr{i: 3}), def); v != def {
+		t.Fatalf("Expected the default, got %v", v)
+	}
+	if v := tree.FetchOr(ovr{i: 1}, def); v.mark != 1 {
+		t.Fatalf("Expected mark 1, got %v", v)
+	}
+	if v := tree.FetchOr(ovr{i: 0}, def); v != def {
+		t.Fatalf("Expected the default, got %v", v)
+	}
+}
+
+func ovrKey(o ovr, key int) int {
+	switch {
+	case o.i < key:
+		return Less
+	case o.i > key:
+		return Greater
+	default:
+		return Equal
+	}
+}
+
+func TestClosureFreeLookups(t *testing.T) {
+	tree := New[ovr](ol, ovr{1, 10}, ovr{5, 50}, ovr{3, 30})
+	if !tree.HasItem(ovr{i: 5}) || tree.HasItem(ovr{i: 4}) {
+		t.Fatalf("HasItem failed")
+	}
+	if v, found := GetKey(tree, 3, ovrKey); !found || v.mark != 30 {
+		t.Fatalf("Expected to find 3, got %v", v)
+	}
+	if _, found := GetKey(tree, 2, ovrKey); found {
+		t.Fatalf("Did not expect to find 2")
+	}
+	if !HasKey(tree, 1, ovrKey) || HasKey(tree, 6, ovrKey) {
+		t.Fatalf("HasKey failed")
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		GetKey(tree, 5, ovrKey)
+		tree.HasItem(ovr{i: 1})
+	})
+	if allocs != 0 {
+		t.Fatalf("Expected no allocations, got %f", allocs)
+	}
+}
+
+func TestIteratorStackPrealloc(t *testing.T) {
+	tree := New[int](il, rand.Perm(10000)...)
+	allocs := testing.AllocsPerRun(20, func() {
+		iter := tree.All()
+		for iter.Next() {
+		}
+	})
+	if allocs > 2 {
+		t.Fatalf("Expected at most 2 allocations for a full walk, got %v", allocs)
+	}
+	allocs = testing.AllocsPerRun(20, func() {
+		iter := tree.Iterator(nil, nil)
+		for iter.Next() {
+		}
+	})
+	if allocs > 2 {
+		t.Fatalf("Expected at most 2 allocations for a full walk, got %v", allocs)
+	}
+}
+
+func TestUpdateItem(t *testing.T) {
+	items := make([]ovr, 1000)
+	for i := range items {
+		items[i] = ovr{i: i, mark: 1}
+	}
+	tree := New[ovr](ol, items...)
+	for i := 0; i < 1000; i += 37 {
+		res, found, err := tree.UpdateItem(tree.Cmp(ovr{i: i}), func(v ovr) ovr {
+			v.mark = 2
+			return v
+		})
+		if !found || err != nil {
+			t.Fatalf("Expected to find %d", i)
+		}
+		res.root.balanced(t)
+		if v, _ := res.Fetch(ovr{i: i}); v.mark != 2 {
+			t.Fatalf("Item %d was not updated", i)
+		}
+		if v, _ := tree.Fetch(ovr{i: i}); v.mark != 1 {
+			t.Fatalf("Item %d was updated in the original Tree", i)
+		}
+		if res.Len() != tree.Len() {
+			t.Fatalf("UpdateItem changed the number of items")
+		}
+		tree = res
+	}
+	res, found, err := tree.UpdateItem(tree.Cmp(ovr{i: 5000}), func(v ovr) ovr { return v })
+	if found || err != nil || res != tree {
+		t.Fatalf("Updating a missing item should return the original Tree")
+	}
+	if _, found, _ = New[ovr](ol).UpdateItem(tree.Cmp(ovr{}), func(v ovr) ovr { return v }); found {
+		t.Fatalf("Updating an empty Tree should fail")
+	}
+	res, found, err = tree.UpdateItem(tree.Cmp(ovr{i: 10}), func(v ovr) ovr {
+		v.i++
+		return v
+	})
+	if !errors.Is(err, ErrKeyChanged) || !found || res != tree {
+		t.Fatalf("Expected ErrKeyChanged and the original Tree when the key changed, got %v", err)
+	}
+	if v, _ := tree.Fetch(ovr{i: 10}); v.i != 10 {
+		t.Fatalf("Failed update modified the Tree")
+	}
+	if _, found = tree.Fetch(ovr{i: 11}); !found || tree.Len() != 1000 {
+		t.Fatalf("Failed update lost an item")
+	}
+}
+
+func TestIterRebase(t *testing.T) {
+	tree := New[int](il, 0, 1, 2, 3, 4)
+	iter := tree.Iterator(nil, Gte(tree.Cmp(100)))
+	seen := []int{}
+	for iter.Next() {
+		seen = append(seen, iter.Item())
+	}
+	// Follow the Tree as more items are added to the end, and some are deleted.
+	for round := 1; round < 5; round++ {
+		tree, _ = tree.DeleteItems(round*5 - 1)
+		tree = tree.Insert(round*5, round*5+1, round*5+2, round*5+3, round*5+4, 200)
+		if !iter.Rebase(tree) {
+			t.Fatalf("Rebase failed")
+		}
+		for iter.Next() {
+			seen = append(seen, iter.Item())
+		}
+	}
+	if len(seen) != 25 {
+		t.Fatalf("Expected 25 items, got %v", seen)
+	}
+	for i := range seen {
+		if seen[i] != i {
+			t.Fatalf("Expected %d at %d, got %v", i, i, seen)
+		}
+	}
+	// Rebase in the middle of iterating, onto a Tree that lacks the current item.
+	iter = tree.Iterator(nil, nil)
+	for iter.Next() && iter.Item() < 10 {
+	}
+	tree, _ = tree.DeleteItems(10, 11)
+	iter.Rebase(tree)
+	if !iter.Next() || iter.Item() != 12 {
+		t.Fatalf("Expected 12 after rebasing")
+	}
+	iter.Rebase(tree)
+	if !iter.Prev() || iter.Item() != 8 {
+		t.Fatalf("Expected 8 going backwards after rebasing")
+	}
+	iter.Release()
+	if iter.Rebase(tree) {
+		t.Fatalf("Rebasing a released iterator should fail")
+	}
+	if tree.All().Rebase(tree) {
+		t.Fatalf("Expected OffsetAndLimit iterators to not support Rebase")
+	}
+}
+
+func TestFloorCeiling(t *testing.T) {
+	tree := New[int](il, 10, 20, 30, 40, 50)
+	for _, tc := range []struct {
+		item, floor, ceil int
+		hasFloor, hasCeil bool
+	}{
+		{5, 0, 10, false, true},
+		{10, 10, 10, true, true},
+		{25, 20, 30, true, true},
+		{50, 50, 50, true, true},
+		{55, 50, 0, true, false},
+	} {
+		if v, ok := tree.Floor(tc.item); v != tc.floor || ok != tc.hasFloor {
+			t.Errorf("Floor(%d): expected %d %v, got %d %v", tc.item, tc.floor, tc.hasFloor, v, ok)
+		}
+		if v, ok := tree.Ceiling(tc.item); v != tc.ceil || ok != tc.hasCeil {
+			t.Errorf("Ceiling(%d): expected %d %v, got %d %v", tc.item, tc.ceil, tc.hasCeil, v, ok)
+		}
+	}
+	if _, ok := New[int](il).Floor(1); ok {
+		t.Errorf("Empty Tree should not have a floor")
+	}
+}
+
+func TestSuccessorPredecessor(t *testing.T) {
+	tree := New[int](il, 10, 20, 30)
+	for _, tc := range []struct {
+		item, succ, pred int
+		hasSucc, hasPred bool
+	}{
+		{5, 10, 0, true, false},
+		{10, 20, 0, true, false},
+		{15, 20, 10, true, true},
+		{20, 30, 10, true, true},
+		{30, 0, 20, false, true},
+		{35, 0, 30, false, true},
+	} {
+		if v, ok := tree.Successor(tc.item); v != tc.succ || ok != tc.hasSucc {
+			t.Errorf("Successor(%d): expected %d %v, got %d %v", tc.item, tc.succ, tc.hasSucc, v, ok)
+		}
+		if v, ok := tree.Predecessor(tc.item); v != tc.pred || ok != tc.hasPred {
+			t.Errorf("Predecessor(%d): expected %d %v, got %d %v", tc.item, tc.pred, tc.hasPred, v, ok)
+		}
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	tree := New[int](il, rand.Perm(50)...).Insert(100)
+	for i := -1; i < 102; i++ {
+		prev, exact, next, hasPrev, hasExact, hasNext := tree.Neighbors(i)
+		ep, eok := tree.Predecessor(i)
+		en, enok := tree.Successor(i)
+		ex, exok := tree.Fetch(i)
+		if prev != ep || hasPrev != eok || next != en || hasNext != enok || exact != ex || hasExact != exok {
+			t.Errorf("Neighbors(%d): got %d %v, %d %v, %d %v", i, prev, hasPrev, exact, hasExact, next, hasNext)
+		}
+	}
+}
+
+func TestPopMinMax(t *testing.T) {
+	tree := New[int](il, rand.Perm(100)...)
+	orig := tree
+	for lo, hi := 0, 99; lo <= hi; lo, hi = lo+1, hi-1 {
+		var v int
+		var ok bool
+		if tree, v, ok = tree.PopMin(); !ok || v != lo {
+			t.Fatalf("PopMin: expected %d, got %d %v", lo, v, ok)
+		}
+		if tree, v, ok = tree.PopMax(); !ok || v != hi {
+			t.Fatalf("PopMax: expected %d, got %d %v", hi, v, ok)
+		}
+		tree.root.balanced(t)
+		if tree.Len() != hi-lo-1 {
+			t.Fatalf("Expected %d items, got %d", hi-lo-1, tree.Len())
+		}
+	}
+	if res, _, ok := tree.PopMin(); ok || res != tree {
+		t.Fatalf("PopMin on an empty Tree should fail")
+	}
+	if orig.Len() != 100 {
+		t.Fatalf("Popping modified the original Tree")
+	}
+}
+
+func TestGetOrInsert(t *testing.T) {
+	tree := New[ovr](ol, ovr{1, 1}, ovr{3, 3})
+	res, existing, inserted := tree.GetOrInsert(ovr{3, 30})
+	if inserted || res != tree || existing.mark != 3 {
+		t.Fatalf("Expected to get the existing item, got %v %v", existing, inserted)
+	}
+	res, existing, inserted = tree.GetOrInsert(ovr{2, 20})
+	if !inserted || res == tree || existing.mark != 20 || res.Len() != 3 || tree.Len() != 2 {
+		t.Fatalf("Expected to insert the new item, got %v %v", existing, inserted)
+	}
+	res.root.balanced(t)
+	ints := New[int](il)
+	for _, v := range rand.Perm(200) {
+		ints, _, _ = ints.GetOrInsert(v % 100)
+	}
+	ints.root.balanced(t)
+	if ints.Len() != 100 {
+		t.Fatalf("Expected 100 items, got %d", ints.Len())
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	sum := func(old, new ovr) ovr { return ovr{old.i, old.mark + new.mark} }
+	tree := New[ovr](ol).Upsert(ovr{1, 1}, sum).Upsert(ovr{1, 2}, sum)
+	if v, _ := tree.Fetch(ovr{i: 1}); v.mark != 3 || tree.Len() != 1 {
+		t.Fatalf("Expected a merged mark of 3, got %v", v)
+	}
+	counts := tree.UpsertWith(func(add func(ovr)) {
+		for i := 0; i < 1000; i++ {
+			add(ovr{i % 10, 1})
+		}
+	}, sum)
+	counts.root.balanced(t)
+	if counts.Len() != 10 {
+		t.Fatalf("Expected 10 items, got %d", counts.Len())
+	}
+	counts.Walk(func(v ovr) bool {
+		expect := 100
+		if v.i == 1 {
+			expect = 103
+		}
+		if v.mark != expect {
+			t.Errorf("Expected %d for %d, got %d", expect, v.i, v.mark)
+		}
+		return true
+	})
+	if v, _ := tree.Fetch(ovr{i: 1}); v.mark != 3 {
+		t.Fatalf("UpsertWith modified the original Tree")
+	}
+}
+
+func TestInsertNoReplace(t *testing.T) {
+	tree := New[ovr](ol, ovr{1, 1}, ovr{2, 2})
+	res, inserted := tree.InsertNoReplace(ovr{2, 20}, ovr{3, 30}, ovr{3, 300})
+	if inserted != 1 || res.Len() != 3 {
+		t.Fatalf("Expected 1 insert, got %d", inserted)
+	}
+	for i, mark := range []int{1, 2, 30} {
+		if v, _ := res.Fetch(ovr{i: i + 1}); v.mark != mark {
+			t.Errorf("Expected mark %d for %d, got %d", mark, i+1, v.mark)
+		}
+	}
+	stable := NewWith[ovr](ol, StableTies()).Insert(ovr{1, 1})
+	if res, inserted := stable.InsertNoReplace(ovr{1, 2}); inserted != 0 || res.Len() != 1 {
+		t.Fatalf("Stable Tree got a second equal item")
+	}
+}
+
+func TestInsertReturning(t *testing.T) {
+	tree := New[ovr](ol, ovr{1, 1})
+	res, old, replaced := tree.InsertReturning(ovr{1, 10})
+	if !replaced || old.mark != 1 || res.FetchOr(ovr{i: 1}, ovr{}).mark != 10 {
+		t.Fatalf("Expected to replace mark 1, got %v %v", old, replaced)
+	}
+	if _, _, replaced = tree.InsertReturning(ovr{2, 2}); replaced {
+		t.Fatalf("Inserting a new item should not replace anything")
+	}
+	res, all := tree.InsertReplacing(ovr{1, 2}, ovr{2, 2}, ovr{1, 3})
+	if !reflect.DeepEqual(all, []ovr{{1, 1}, {1, 2}}) || res.Len() != 2 {
+		t.Fatalf("Unexpected replaced items %v", all)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	incr := func(key int) func(ovr, bool) (ovr, bool) {
+		return func(old ovr, found bool) (ovr, bool) {
+			if !found {
+				return ovr{key, 1}, true
+			}
+			return ovr{key, old.mark + 1}, old.mark < 2
+		}
+	}
+	tree := New[ovr](ol)
+	for i := 0; i < 3; i++ {
+		for key := 0; key < 50; key++ {
+			res, found, err := tree.Update(tree.Cmp(ovr{i: key}), incr(key))
+			if err != nil || found != (i > 0) {
+				t.Fatalf("Pass %d: unexpected found %v and error %v", i, found, err)
+			}
+			tree = res
+			tree.root.balanced(t)
+		}
+		expect := 50
+		if i == 2 {
+			expect = 0
+		}
+		if tree.Len() != expect {
+			t.Fatalf("Pass %d: expected %d items, got %d", i, expect, tree.Len())
+		}
+		tree.Walk(func(v ovr) bool {
+			if v.mark != i+1 {
+				t.Fatalf("Pass %d: unexpected item %v", i, v)
+			}
+			return true
+		})
+	}
+	skip := func(ovr, bool) (ovr, bool) { return ovr{}, false }
+	if res, found, err := tree.Update(tree.Cmp(ovr{i: 1}), skip); res != tree || found || err != nil {
+		t.Fatalf("Update that changes nothing should return the original Tree")
+	}
+	tree = tree.Insert(ovr{1, 7})
+	res, found, err := tree.Update(tree.Cmp(ovr{i: 1}), func(ovr, bool) (ovr, bool) { return ovr{i: 2}, true })
+	if !errors.Is(err, ErrKeyChanged) || !found || res != tree {
+		t.Fatalf("Storing an item that is not equal to cmp should return ErrKeyChanged, got %v", err)
+	}
+	if v, _ := tree.Fetch(ovr{i: 1}); v.mark != 7 || tree.Has(tree.Cmp(ovr{i: 2})) {
+		t.Fatalf("Failed Update modified the Tree")
+	}
+}
+
+func TestDeepClone(t *testing.T) {
+	type boxed struct{ v *int }
+	tree := New[boxed](func(a, b boxed) bool { return *a.v < *b.v })
+	for _, i := range rand.Perm(100) {
+		i := i
+		tree = tree.Insert(boxed{&i})
+	}
+	order := []int{}
+	clone := tree.DeepClone(func(b boxed) boxed {
+		v := *b.v
+		order = append(order, v)
+		return boxed{&v}
+	})
+	clone.root.balanced(t)
+	orig := map[*node[boxed]]bool{}
+	for _, n := range collectNodes(tree.root, nil) {
+		orig[n] = true
+	}
+	for i, n := range collectNodes(clone.root, nil) {
+		if orig[n] || order[i] != i || *n.i.v != i {
+			t.Fatalf("Clone shares nodes or items with the original Tree, or is out of order")
+		}
+		if v, _ := tree.At(i); v.v == n.i.v {
+			t.Fatalf("Item %d was not deep copied", i)
+		}
+	}
+	if shallow := tree.DeepClone(nil); shallow.Len() != 100 || shallow.root == tree.root || shallow.root.i != tree.root.i {
+		t.Fatalf("A nil clone function should copy nodes but not items")
+	}
+}
+
+func TestIterSeek(t *testing.T) {
+	tree := New[int](il)
+	for i := 0; i < 100; i++ {
+		tree = tree.Insert(i * 2)
+	}
+	for name, iter := range map[string]Iter[int]{
+		"Iterator":       tree.Iterator(Lt(tree.Cmp(10)), Gt(tree.Cmp(150))),
+		"OffsetAndLimit": tree.OffsetAndLimit(5, 71),
+	} {
+		// Both Iters cover 10 through 150.
+		for _, tc := range []struct{ seek, expect int }{{41, 42}, {20, 20}, {0, 10}, {151, -1}} {
+			found := iter.Seek(tree.Cmp(tc.seek))
+			if found != (tc.expect >= 0) || (found && iter.Item() != tc.expect) {
+				t.Fatalf("%s: Seek(%d) expected %d", name, tc.seek, tc.expect)
+			}
+			if tc.expect == 10 {
+				iter.Next()
+				iter.Next()
+				if iter.Item() != 14 {
+					t.Fatalf("%s: expected Next to carry on after Seek, got %d", name, iter.Item())
+				}
+			}
+		}
+		if iter.Next() || iter.Seek(tree.Cmp(50)) {
+			t.Fatalf("%s: Seek past the end should finish the Iter", name)
+		}
+	}
+	iter := tree.OffsetAndLimit(0, 10)
+	iter.Seek(tree.Cmp(12))
+	n := 1
+	for iter.Next() {
+		n++
+	}
+	if n != 4 {
+		t.Fatalf("Seek did not keep the limit, got %d items", n)
+	}
+	if tree.NewSince(New[int](il)).Seek(tree.Cmp(1)) {
+		t.Fatalf("NewSince Iters cannot seek")
+	}
+}
+
+func TestIterClone(t *testing.T) {
+	tree := New[int](il, rand.Perm(50)...)
+	next := tree.Insert(100, 101)
+	for name, iter := range map[string]Iter[int]{
+		"Iterator":       tree.Iterator(nil, nil),
+		"OffsetAndLimit": tree.OffsetAndLimit(0, -1),
+		"NewSince":       next.NewSince(tree),
+	} {
+		iter.Next()
+		first := iter.Item()
+		probe := iter.Clone()
+		for probe.Next() {
+		}
+		if iter.Item() != first || !iter.Next() || iter.Item() <= first {
+			t.Fatalf("%s: moving the clone moved the original", name)
+		}
+		second := iter.Item()
+		ahead := iter.Clone()
+		if ahead.Item() != second || !ahead.Next() || !iter.Next() || ahead.Item() != iter.Item() {
+			t.Fatalf("%s: clone did not start at the same position", name)
+		}
+	}
+}
+
+func TestIterPeek(t *testing.T) {
+	tree := New[int](il, rand.Perm(100)...)
+	next := tree.Insert(200, 201)
+	for name, iter := range map[string]Iter[int]{
+		"Iterator":       tree.Iterator(nil, Gt(tree.Cmp(50))),
+		"OffsetAndLimit": tree.OffsetAndLimit(3, 20),
+		"NewSince":       next.NewSince(tree),
+		"Descending":     tree.Iterator(nil, nil),
+	} {
+		if name == "Descending" {
+			iter.Prev()
+			iter.Prev()
+		}
+		for {
+			peeked, ok := iter.Peek()
+			again, _ := iter.Peek()
+			if peeked != again {
+				t.Fatalf("%s: Peek moved the Iter", name)
+			}
+			if ok != iter.Next() {
+				t.Fatalf("%s: Peek and Next disagree about whether there is a next item", name)
+			}
+			if !ok {
+				break
+			}
+			if iter.Item() != peeked {
+				t.Fatalf("%s: Peek returned %d, but Next moved to %d", name, peeked, iter.Item())
+			}
+		}
+	}
+}
+
+func TestIterReset(t *testing.T) {
+	small := New[int](il, 1, 2, 3)
+	big := New[int](il, rand.Perm(1000)...)
+	iter := small.Iterator(nil, nil)
+	for iter.Next() {
+	}
+	if !iter.Reset(big, Lt(big.Cmp(500)), Gte(big.Cmp(510))) {
+		t.Fatalf("Reset failed")
+	}
+	n := 500
+	for iter.Next() {
+		if iter.Item() != n {
+			t.Fatalf("Expected %d, got %d", n, iter.Item())
+		}
+		n++
+	}
+	if n != 510 {
+		t.Fatalf("Expected to stop at 510, got %d", n)
+	}
+	iter.Release()
+	if !iter.Reset(small, nil, nil) || !iter.Prev() || iter.Item() != 3 {
+		t.Fatalf("Reset after Release failed")
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		iter.Reset(big, nil, nil)
+		for iter.Next() {
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("Expected no allocations, got %f", allocs)
+	}
+	if big.All().Reset(big, nil, nil) {
+		t.Fatalf("OffsetAndLimit Iters cannot be reset")
+	}
+}
+
+func TestReverseOffsetAndLimit(t *testing.T) {
+	tree := New[int](il, rand.Perm(100)...)
+	for _, tc := range []struct{ offset, limit, first, n int }{{0, 10, 99, 10}, {20, 10, 79, 10}, {95, 10, 4, 5}, {0, -1, 99, 100}, {100, 5, 0, 0}} {
+		iter := tree.ReverseOffsetAndLimit(tc.offset, tc.limit)
+		if p, ok := iter.Peek(); ok != (tc.n > 0) || (ok && p != tc.first) {
+			t.Fatalf("Peek at offset %d: expected %d, got %d", tc.offset, tc.first, p)
+		}
+		n := 0
+		for iter.Next() {
+			if iter.Item() != tc.first-n {
+				t.Fatalf("Offset %d: expected %d, got %d", tc.offset, tc.first-n, iter.Item())
+			}
+			if p, ok := iter.Peek(); ok && p != iter.Item()-1 {
+				t.Fatalf("Peek after %d returned %d", iter.Item(), p)
+			}
+			n++
+		}
+		if n != tc.n {
+			t.Fatalf("Offset %d limit %d: expected %d items, got %d", tc.offset, tc.limit, tc.n, n)
+		}
+	}
+	if tree.ReverseOffsetAndLimit(0, 5).Seek(tree.Cmp(3)) {
+		t.Fatalf("Descending OffsetAndLimit Iters cannot seek")
+	}
+}
+
+func TestBoundedOffsetAndLimit(t *testing.T) {
+	tree := New[int](il, rand.Perm(100)...)
+	start, stop := Lt(tree.Cmp(20)), Gte(tree.Cmp(40))
+	for _, tc := range []struct{ offset, limit, first, n int }{{0, 5, 20, 5}, {5, 5, 25, 5}, {15, 10, 35, 5}, {0, -1, 20, 20}, {25, 5, 0, 0}} {
+		iter := tree.BoundedOffsetAndLimit(start, stop, tc.offset, tc.limit)
+		n := 0
+		for iter.Next() {
+			if iter.Item() != tc.first+n {
+				t.Fatalf("Offset %d: expected %d, got %d", tc.offset, tc.first+n, iter.Item())
+			}
+			n++
+		}
+		if n != tc.n {
+			t.Fatalf("Offset %d limit %d: expected %d items, got %d", tc.offset, tc.limit, tc.n, n)
+		}
+	}
+	if tree.BoundedOffsetAndLimit(Lt(tree.Cmp(50)), Gt(tree.Cmp(10)), 0, -1).Next() {
+		t.Fatalf("Crossed bounds should not return anything")
+	}
+}
+
+func TestOffsetAndLimitPrev(t *testing.T) {
+	tree := New[int](il, rand.Perm(100)...)
+	for _, desc := range []bool{false, true} {
+		iter := tree.OffsetAndLimit(10, 20)
+		at := func(pos int) int { return pos }
+		if desc {
+			iter = tree.ReverseOffsetAndLimit(10, 20)
+			at = func(pos int) int { return 99 - pos }
+		}
+		if !iter.Prev() || iter.Item() != at(29) {
+			t.Fatalf("desc %v: Prev before Next should start at the end of the window", desc)
+		}
+		for i := 28; i >= 20; i-- {
+			if !iter.Prev() || iter.Item() != at(i) {
+				t.Fatalf("desc %v: expected %d, got %d", desc, at(i), iter.Item())
+			}
+		}
+		for i := 21; i < 30; i++ {
+			if !iter.Next() || iter.Item() != at(i) {
+				t.Fatalf("desc %v: expected %d, got %d", desc, at(i), iter.Item())
+			}
+		}
+		if iter.Next() {
+			t.Fatalf("desc %v: Next went past the end of the window", desc)
+		}
+		iter = tree.OffsetAndLimit(10, 20)
+		if desc {
+			iter = tree.ReverseOffsetAndLimit(10, 20)
+		}
+		iter.Next()
+		if iter.Prev() {
+			t.Fatalf("desc %v: Prev went past the start of the window", desc)
+		}
+	}
+}
+
+func TestOffsetAndLimitWalkBack(t *testing.T) {
+	tree := New[int](il, rand.Perm(1000)...)
+	for _, desc := range []bool{false, true} {
+		iter, at := tree.All(), func(pos int) int { return pos }
+		if desc {
+			iter, at = tree.ReverseOffsetAndLimit(0, -1), func(pos int) int { return 999 - pos }
+		}
+		for i := 999; i >= 0; i-- {
+			if !iter.Prev() || iter.Item() != at(i) {
+				t.Fatalf("desc %v: expected %d going back", desc, at(i))
+			}
+			if p, ok := iter.Peek(); ok != (i < 999) || (ok && p != at(i+1)) {
+				t.Fatalf("desc %v: Peek at %d returned %d, %v", desc, at(i), p, ok)
+			}
+		}
+		for i := 1; i < 999; i++ {
+			if !iter.Next() || !iter.Next() || !iter.Prev() || iter.Item() != at(i) {
+				t.Fatalf("desc %v: expected %d going back and forth", desc, at(i))
+			}
+		}
+	}
+}
+
+func TestOffsetAndLimitSkip(t *testing.T) {
+	tree := New[int](il, rand.Perm(5000)...)
+	for _, offset := range []int{0, 1, 2500, 4999, 5000, -3} {
+		iter := tree.OffsetAndLimit(offset, 3)
+		expect := offset
+		if expect < 0 {
+			expect = 0
+		}
+		for iter.Next() {
+			if iter.Item() != expect {
+				t.Fatalf("Offset %d: expected %d, got %d", offset, expect, iter.Item())
+			}
+			expect++
+		}
+		if offset < 4998 && expect != offset+3 && offset >= 0 {
+			t.Fatalf("Offset %d: stopped early at %d", offset, expect)
+		}
+	}
+}
+
+func TestWalkCtx(t *testing.T) {
+	tree := New[int](il, rand.Perm(1000)...)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	if err := tree.WalkCtx(ctx, func(int) bool { n++; return true }); err != nil || n != 1000 {
+		t.Fatalf("Expected to walk 1000 items, got %d %v", n, err)
+	}
+	n = 0
+	err := tree.RangeCtx(ctx, Lt(tree.Cmp(100)), nil, func(i int) bool {
+		if n++; i == 199 {
+			cancel()
+		}
+		return true
+	})
+	if !errors.Is(err, context.Canceled) || n != 100 {
+		t.Fatalf("Expected to stop after 100 items, got %d %v", n, err)
+	}
+	if err = tree.WalkCtx(context.Background(), func(int) bool { return false }); err != nil {
+		t.Fatalf("Stopping early should not be an error, got %v", err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	tree := New[int](il, rand.Perm(500)...)
+	n := 100
+	for i := range tree.Stream(context.Background(), Lt(tree.Cmp(100)), Gte(tree.Cmp(200))) {
+		if i != n {
+			t.Fatalf("Expected %d, got %d", n, i)
+		}
+		n++
+	}
+	if n != 200 {
+		t.Fatalf("Expected to stop at 200, got %d", n)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := tree.Stream(ctx, nil, nil)
+	<-ch
+	cancel()
+	n = 0
+	for range ch {
+		n++
+	}
+	if n > 1 {
+		t.Fatalf("Stream kept sending %d items after being cancelled", n)
+	}
+}
+
+func TestFilterIter(t *testing.T) {
+	tree := New[int](il)
+	for i := 0; i < 100; i++ {
+		tree = tree.Insert(i)
+	}
+	even := func(v int) bool { return v%2 == 0 }
+	iter := FilterIter(tree.Iterator(nil, nil), even)
+	n := 0
+	for iter.Next() {
+		if iter.Item() != n*2 {
+			t.Fatalf("Expected %d, got %d", n*2, iter.Item())
+		}
+		n++
+	}
+	if n != 50 {
+		t.Fatalf("Expected 50 items, got %d", n)
+	}
+	iter = FilterIter(tree.Iterator(nil, nil), even)
+	if !iter.Seek(tree.Cmp(41)) || iter.Item() != 42 {
+		t.Fatalf("Seek did not skip to 42")
+	}
+	if p, ok := iter.Peek(); !ok || p != 44 {
+		t.Fatalf("Expected to peek 44, got %d", p)
+	}
+	if !iter.Prev() || iter.Item() != 40 {
+		t.Fatalf("Prev did not skip back to 40")
+	}
+	odd := FilterIter(FilterIter(tree.Iterator(Lt(tree.Cmp(10)), nil), func(v int) bool { return !even(v) }),
+		func(v int) bool { return v%5 == 0 })
+	for _, want := range []int{15, 25, 35} {
+		if !odd.Next() || odd.Item() != want {
+			t.Fatalf("Expected %d from stacked filters", want)
+		}
+	}
+	odd.Release()
+}
+
+func TestWalkChunks(t *testing.T) {
+	tree := New[int](il)
+	for i := 0; i < 103; i++ {
+		tree = tree.Insert(i)
+	}
+	for _, size := range []int{0, 1, 10, 103, 500} {
+		var sizes []int
+		next := 0
+		tree.WalkChunks(size, func(chunk []int) bool {
+			sizes = append(sizes, len(chunk))
+			for _, v := range chunk {
+				if v != next {
+					t.Fatalf("Chunk of %d: expected %d, got %d", size, next, v)
+				}
+				next++
+			}
+			return true
+		})
+		if next != 103 {
+			t.Fatalf("Chunk of %d: only saw %d items", size, next)
+		}
+		want := size
+		if want < 1 {
+			want = 1
+		}
+		for i, n := range sizes[:len(sizes)-1] {
+			if n != want {
+				t.Fatalf("Chunk of %d: chunk %d held %d items", size, i, n)
+			}
+		}
+	}
+	calls := 0
+	tree.WalkChunks(10, func([]int) bool { calls++; return calls < 3 })
+	if calls != 3 {
+		t.Fatalf("WalkChunks did not stop early: %d calls", calls)
+	}
+	New[int](il).WalkChunks(10, func([]int) bool {
+		t.Fatalf("Empty Tree should not produce chunks")
+		return false
+	})
+}
+
+func TestWalkLevels(t *testing.T) {
+	tree := New[int](il)
+	for _, v := range rand.New(rand.NewSource(66)).Perm(500) {
+		tree = tree.Insert(v)
+	}
+	var order []int
+	last := 0
+	tree.WalkLevels(func(v, depth int) bool {
+		if len(order) == 0 && (v != tree.root.i || depth != 0) {
+			t.Fatalf("Expected to start at the root")
+		}
+		if depth < last || depth > last+1 {
+			t.Fatalf("Depth went from %d to %d", last, depth)
+		}
+		last = depth
+		order = append(order, v)
+		return true
+	})
+	if len(order) != 500 {
+		t.Fatalf("Expected 500 items, got %d", len(order))
+	}
+	if last != height(tree.root)-1 {
+		t.Fatalf("Deepest level was %d, Tree height is %d", last, height(tree.root))
+	}
+	rebuilt := New[int](il)
+	for _, v := range order {
+		rebuilt = rebuilt.Insert(v)
+	}
+	var again []int
+	rebuilt.WalkLevels(func(v, _ int) bool {
+		again = append(again, v)
+		return true
+	})
+	for i := range order {
+		if order[i] != again[i] {
+			t.Fatalf("Rebuilt Tree has a different shape at %d", i)
+		}
+	}
+	n := 0
+	tree.WalkLevels(func(int, int) bool { n++; return n < 10 })
+	if n != 10 {
+		t.Fatalf("WalkLevels did not stop early: %d", n)
+	}
+}
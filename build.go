@@ -0,0 +1,319 @@
+package ibtree
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// buildSorted builds a perfectly balanced subtree out of items, which must already
+// be in the order the subtree should hold them in.  Equal items are kept as they are,
+// so items must only hold runs of equal items if they are meant for a Tree created
+// with StableTies.  All the new nodes will be created with generation gen.
+func buildSorted[T any](items []T, gen uint64) *node[T] {
+	if len(items) == 0 {
+		return nil
+	}
+	mid := len(items) / 2
+	res := &node[T]{i: items[mid], genH: gen << hOffset}
+	res.l = buildSorted(items[:mid], gen)
+	res.r = buildSorted(items[mid+1:], gen)
+	res.setHeight()
+	return res
+}
+
+// mergeRuns merges the sorted runs a and b into dst, which must have
+// room for both.  Items in a sort before equal items in b.
+func mergeRuns[T any](lt LessThan[T], dst, a, b []T) {
+	i, j, k := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if lt(b[j], a[i]) {
+			dst[k] = b[j]
+			j++
+		} else {
+			dst[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
+
+// dedupLast collapses runs of equal items in sorted down to the last
+// item in each run, which is the one that would have won had the items
+// been inserted one at a time.
+func dedupLast[T any](lt LessThan[T], sorted []T) []T {
+	if len(sorted) < 2 {
+		return sorted
+	}
+	res := sorted[:0]
+	for i := range sorted {
+		if i+1 < len(sorted) && !lt(sorted[i], sorted[i+1]) {
+			continue
+		}
+		res = append(res, sorted[i])
+	}
+	return res
+}
+
+// sortParallel stably sorts items using up to workers goroutines.  items
+// is split into one chunk per worker, each chunk is sorted independently,
+// and then the chunks are merged pairwise until only one run remains.
+func sortParallel[T any](lt LessThan[T], items []T, workers int) []T {
+	if workers > len(items) {
+		workers = len(items)
+	}
+	if workers < 2 {
+		sort.SliceStable(items, func(i, j int) bool { return lt(items[i], items[j]) })
+		return items
+	}
+	size := (len(items) + workers - 1) / workers
+	runs := make([][]T, 0, workers)
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		runs = append(runs, items[start:end])
+	}
+	wg := &sync.WaitGroup{}
+	for _, run := range runs {
+		wg.Add(1)
+		go func(run []T) {
+			defer wg.Done()
+			sort.SliceStable(run, func(i, j int) bool { return lt(run[i], run[j]) })
+		}(run)
+	}
+	wg.Wait()
+	src, dst := items, make([]T, len(items))
+	for len(runs) > 1 {
+		merged := make([][]T, 0, (len(runs)+1)/2)
+		offset := 0
+		for i := 0; i < len(runs); i += 2 {
+			if i+1 == len(runs) {
+				out := dst[offset : offset+len(runs[i])]
+				copy(out, runs[i])
+				merged = append(merged, out)
+				break
+			}
+			a, b := runs[i], runs[i+1]
+			out := dst[offset : offset+len(a)+len(b)]
+			offset += len(out)
+			merged = append(merged, out)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mergeRuns(lt, out, a, b)
+			}()
+		}
+		wg.Wait()
+		runs = merged
+		src, dst = dst, src
+	}
+	return src
+}
+
+// CreateWithParallel creates a new Tree that is pre-filled with fill, just like CreateWith.
+// Instead of inserting items one at a time, it buffers everything fill produces,
+// sorts the buffer in chunks on up to workers goroutines, merges the sorted chunks,
+// and builds the Tree directly from the merged result without any rebalancing.
+// If workers is less than 1, runtime.GOMAXPROCS(0) is used instead.
+//
+// As with CreateWith, if fill produces several items that are equal to each other,
+// the last one produced will be the one in the Tree.
+func CreateWithParallel[T any](lt LessThan[T], workers int, fill Fill[T]) *Tree[T] {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	var buf []T
+	fill(func(i T) {
+		buf = append(buf, i)
+	})
+	res := New[T](lt)
+	items := dedupLast(lt, sortParallel(lt, buf, workers))
+	res.root = buildSorted(items, res.gen)
+	res.count = len(items)
+	return res
+}
+
+// loader builds a new Tree out of a stream of items.  As long as the items
+// arrive in ascending order they are buffered, and the Tree is built directly
+// from the buffer once the stream ends.  If an item arrives out of order, the
+// buffer is turned into a Tree and the rest of the items are inserted one at a time.
+type loader[T any] struct {
+	t      *Tree[T]
+	ins    *nodeStack[T]
+	sorted []T
+}
+
+func newLoader[T any](t *Tree[T]) *loader[T] {
+	return &loader[T]{t: t}
+}
+
+func (l *loader[T]) spill() {
+	l.t.root = buildSorted(l.sorted, l.t.gen)
+	l.t.count = len(l.sorted)
+	l.sorted = nil
+	l.ins = l.t.getNsp()
+}
+
+func (l *loader[T]) add(v T) {
+	if l.ins == nil {
+		last := len(l.sorted) - 1
+		switch {
+		case last == -1 || l.t.less(l.sorted[last], v):
+			l.sorted = append(l.sorted, v)
+			return
+		case !l.t.less(v, l.sorted[last]):
+			if l.t.opts.stable {
+				l.sorted = append(l.sorted, v)
+			} else {
+				l.sorted[last] = v
+			}
+			return
+		}
+		l.spill()
+	}
+	l.t.insertOne(l.ins, v)
+}
+
+func (l *loader[T]) finish() *Tree[T] {
+	if l.ins == nil {
+		l.spill()
+	}
+	l.t.putNsp(l.ins)
+	l.ins = nil
+	return l.t
+}
+
+// Filter returns a new Tree holding the items in t that keep returns true for.  Since the
+// kept items come out of t in order, the new Tree is built directly from them without any
+// rebalancing, and it does not share any nodes with t.  Use Retain instead if most items will
+// be kept and sharing nodes with t matters more than building a fresh, perfectly balanced Tree.
+func (t *Tree[T]) Filter(keep Test[T]) *Tree[T] {
+	var items []T
+	t.Walk(func(item T) bool {
+		if keep(item) {
+			items = append(items, item)
+		}
+		return true
+	})
+	gen := t.nextGen()
+	return t.derive(buildSorted(items, gen), len(items), gen)
+}
+
+// Map returns a new Tree ordered by less that holds the result of calling f on every item in t.
+// The new Tree has the same options as t.  The results are collected in the order f is called,
+// which is ascending order for t, so when f preserves that order the new Tree is built directly
+// from them in O(n) time.  Otherwise the results are sorted first.  As with Insert, if f returns
+// several equal items, only the last one is kept unless t was created with StableTies.
+func Map[T, U any](t *Tree[T], f func(T) U, less LessThan[U]) *Tree[U] {
+	res := &Tree[U]{less: less, opts: t.opts}
+	res.nsp = newPool[U](res.opts, nil)
+	items := make([]U, 0, t.count)
+	sorted := true
+	t.Walk(func(item T) bool {
+		v := f(item)
+		if last := len(items) - 1; last >= 0 && less(v, items[last]) {
+			sorted = false
+		}
+		items = append(items, v)
+		return true
+	})
+	if !sorted {
+		sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+	}
+	if !res.opts.stable {
+		items = dedupLast(less, items)
+	}
+	res.root = buildSorted(items, res.gen)
+	res.count = len(items)
+	return res
+}
+
+// Partition splits t into a new Tree holding the items match returns true for, and one holding
+// the rest.  It takes a single pass over t, and builds both Trees the same way Filter does.
+func (t *Tree[T]) Partition(match Test[T]) (matching, rest *Tree[T]) {
+	var yes, no []T
+	t.Walk(func(item T) bool {
+		if match(item) {
+			yes = append(yes, item)
+		} else {
+			no = append(no, item)
+		}
+		return true
+	})
+	gen := t.nextGen()
+	return t.derive(buildSorted(yes, gen), len(yes), gen), t.derive(buildSorted(no, gen), len(no), gen)
+}
+
+// GroupBy buckets the items in t by the key that key extracts from them, and returns a Tree
+// holding one Pair per key ordered by less on the keys.  The First component of each Pair is
+// the key, and the Second is a Tree with the same ordering and options as t that holds every
+// item in t with that key.  Use CmpFirst to look groups up by key.  The items are only walked
+// once, and every Tree is built directly from sorted items without any rebalancing.
+func GroupBy[T, K any](t *Tree[T], key func(T) K, less LessThan[K]) *Tree[Pair[K, *Tree[T]]] {
+	keyed := make([]Pair[K, T], 0, t.count)
+	t.Walk(func(item T) bool {
+		keyed = append(keyed, MakePair(key(item), item))
+		return true
+	})
+	// A stable sort keeps the items in each group in the order t had them in.
+	sort.SliceStable(keyed, func(i, j int) bool { return less(keyed[i].First, keyed[j].First) })
+	gen := t.nextGen()
+	var groups []Pair[K, *Tree[T]]
+	for start := 0; start < len(keyed); {
+		end := start + 1
+		for end < len(keyed) && !less(keyed[start].First, keyed[end].First) {
+			end++
+		}
+		items := make([]T, end-start)
+		for i := range items {
+			items[i] = keyed[start+i].Second
+		}
+		groups = append(groups, MakePair(keyed[start].First, t.derive(buildSorted(items, gen), len(items), gen)))
+		start = end
+	}
+	res := New[Pair[K, *Tree[T]]](func(a, b Pair[K, *Tree[T]]) bool { return less(a.First, b.First) })
+	res.root = buildSorted(groups, res.gen)
+	res.count = len(groups)
+	return res
+}
+
+// NewFromSorted builds a new Tree with opts applied to it out of items, which must already
+// be sorted according to lt.  The Tree is built by recursively splitting items at their
+// midpoint, which takes O(n) time and leaves it perfectly balanced without any rotations.
+// If items holds equal items, only the last of each run of them is kept unless StableTies
+// is one of opts, just as if they had been inserted one at a time.  items is not modified.
+//
+// NewFromSorted checks the order of items as it goes, and panics if it is not sorted.
+func NewFromSorted[T any](lt LessThan[T], items []T, opts ...Option) *Tree[T] {
+	res := NewWith[T](lt, opts...)
+	dups := false
+	for i := 1; i < len(items); i++ {
+		if lt(items[i], items[i-1]) {
+			panic("NewFromSorted: items are not sorted")
+		}
+		dups = dups || !lt(items[i-1], items[i])
+	}
+	if dups && !res.opts.stable {
+		items = dedupLast(lt, append([]T(nil), items...))
+	}
+	res.root = buildSorted(items, res.gen)
+	res.count = len(items)
+	return res
+}
+
+// NewFromIter builds a new Tree with opts applied to it out of the items src yields, such as
+// the output of another Tree's Iterator.  As long as src yields its items in sorted order,
+// they are buffered and the Tree is built from them in O(n) time without any rebalancing,
+// the same way NewFromSorted does.  If an item arrives out of order, NewFromIter falls back
+// to inserting the rest of the items one at a time instead of failing.
+func NewFromIter[T any](lt LessThan[T], src Iter[T], opts ...Option) *Tree[T] {
+	l := newLoader(NewWith[T](lt, opts...))
+	for src.Next() {
+		l.add(src.Item())
+	}
+	return l.finish()
+}
@@ -0,0 +1,149 @@
+package ibtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCreateWithParallel(t *testing.T) {
+	src := rand.New(rand.NewSource(12))
+	for _, workers := range []int{0, 1, 3, 8} {
+		backing := src.Perm(10000)
+		tree := CreateWithParallel[ovr](ol, workers, func(f func(ovr)) {
+			for i := range backing {
+				f(ovr{i: backing[i] % 5000, mark: i})
+			}
+		})
+		expect := CreateWith[ovr](ol, func(f func(ovr)) {
+			for i := range backing {
+				f(ovr{i: backing[i] % 5000, mark: i})
+			}
+		})
+		tree.root.balanced(t)
+		if tree.Len() != expect.Len() {
+			t.Fatalf("workers %d: expected %d items, got %d", workers, expect.Len(), tree.Len())
+		}
+		a, b := tree.All(), expect.All()
+		for a.Next() && b.Next() {
+			if a.Item() != b.Item() {
+				t.Fatalf("workers %d: expected %v, got %v", workers, b.Item(), a.Item())
+			}
+		}
+	}
+	empty := CreateWithParallel[int](il, 4, func(func(int)) {})
+	if empty.Len() != 0 || empty.root != nil {
+		t.Fatalf("Expected an empty tree")
+	}
+	tree := CreateWithParallel[int](il, 4, func(f func(int)) { f(1) })
+	tree = tree.Insert(0, 2)
+	tree.root.balanced(t)
+	if tree.Len() != 3 {
+		t.Fatalf("Expected 3 items, got %d", tree.Len())
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tree := New[int](il, rand.Perm(1000)...)
+	res := tree.Filter(func(i int) bool { return i%7 == 0 })
+	expect := []int{}
+	for i := 0; i < 1000; i += 7 {
+		expect = append(expect, i)
+	}
+	checkTree(t, res, expect)
+	checkTree(t, tree.Filter(func(int) bool { return false }), []int{})
+	if tree.Len() != 1000 {
+		t.Fatalf("Filter modified the original Tree")
+	}
+}
+
+func TestMap(t *testing.T) {
+	tree := New[int](il, rand.Perm(500)...)
+	double := Map(tree, func(i int) ovr { return ovr{i: i * 2, mark: i} }, ol)
+	double.root.balanced(t)
+	if double.Len() != 500 {
+		t.Fatalf("Expected 500 items, got %d", double.Len())
+	}
+	if v, _ := double.Fetch(ovr{i: 84}); v.mark != 42 {
+		t.Fatalf("Expected mark 42, got %v", v)
+	}
+	// Reversing the order and collapsing items exercises the sorting and deduplicating path.
+	folded := Map(tree, func(i int) int { return (499 - i) / 2 }, il)
+	expect := make([]int, 250)
+	for i := range expect {
+		expect[i] = i
+	}
+	checkTree(t, folded, expect)
+	stable := Map(NewWith[int](il, StableTies()).Insert(1, 2, 3), func(int) int { return 0 }, il)
+	if stable.Len() != 3 {
+		t.Fatalf("Expected a StableTies Tree to keep equal items, got %d", stable.Len())
+	}
+}
+
+func TestPartition(t *testing.T) {
+	tree := New[int](il, rand.Perm(300)...)
+	even, odd := tree.Partition(func(i int) bool { return i%2 == 0 })
+	var evens, odds []int
+	for i := 0; i < 300; i += 2 {
+		evens, odds = append(evens, i), append(odds, i+1)
+	}
+	checkTree(t, even, evens)
+	checkTree(t, odd, odds)
+}
+
+func TestGroupBy(t *testing.T) {
+	tree := New[int](il, rand.Perm(100)...)
+	groups := GroupBy(tree, func(i int) int { return i % 7 }, il)
+	groups.root.balanced(t)
+	if groups.Len() != 7 {
+		t.Fatalf("Expected 7 groups, got %d", groups.Len())
+	}
+	for k := 0; k < 7; k++ {
+		group, found := groups.Get(CmpFirst[int, *Tree[int]](il, k))
+		if !found || group.First != k {
+			t.Fatalf("Missing group %d", k)
+		}
+		expect := []int{}
+		for i := k; i < 100; i += 7 {
+			expect = append(expect, i)
+		}
+		checkTree(t, group.Second, expect)
+	}
+	if GroupBy(New[int](il), func(i int) int { return i }, il).Len() != 0 {
+		t.Fatalf("Grouping an empty Tree should give no groups")
+	}
+}
+
+func TestNewFromSorted(t *testing.T) {
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i
+	}
+	checkTree(t, NewFromSorted(il, items), items)
+	dups := []ovr{{1, 1}, {1, 2}, {2, 3}, {2, 4}, {3, 5}}
+	tree := NewFromSorted(ol, dups)
+	if tree.Len() != 3 || tree.FetchOr(ovr{i: 2}, ovr{}).mark != 4 || dups[1].mark != 2 {
+		t.Fatalf("Expected the last of each run of equal items to be kept")
+	}
+	if NewFromSorted(ol, dups, StableTies()).Len() != 5 {
+		t.Fatalf("Expected StableTies to keep every item")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Unsorted items should panic")
+		}
+	}()
+	NewFromSorted(il, []int{1, 3, 2})
+}
+
+func TestNewFromIter(t *testing.T) {
+	src := New[int](il, rand.Perm(500)...)
+	expect := src.Items()
+	checkTree(t, NewFromIter(il, src.All()), expect)
+	odd := src.Retain(func(i int) bool { return i%2 == 1 })
+	even := NewFromIter(il, src.Difference(odd).All(), StableTies())
+	if !even.opts.stable || even.Len() != 250 {
+		t.Fatalf("Expected 250 items with StableTies set")
+	}
+	// Out of order input still has to produce a correct Tree.
+	checkTree(t, NewFromIter(il, src.Reverse().All()), expect)
+}
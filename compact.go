@@ -0,0 +1,42 @@
+package ibtree
+
+// CompactAfter makes the Tree keep track of how many items have been deleted from it
+// since it was last rebuilt.  Once that number is more than ratio times the number of items
+// left in the Tree, the next bulk operation (Insert, InsertWith, InsertFrom, InsertReplacing,
+// InsertNoReplace, UpsertWith, DeleteWith, DeleteFrom, DeleteItems, ApplyOps, or
+// MergeSnapshot) finishes by rebuilding the Tree the same way Compact does.  Trees that
+// shrink a great deal otherwise keep the deep paths and scattered nodes they had when
+// they were large.
+//
+// A ratio of 0 or less turns automatic compaction off, which is the default.
+func CompactAfter(ratio float64) Option {
+	return func(o *options) {
+		o.compactRatio = ratio
+	}
+}
+
+// compactIfNeeded rebuilds t using ins if enough items have been deleted from it.
+func (t *Tree[T]) compactIfNeeded(ins *nodeStack[T]) {
+	if t.opts.compactRatio <= 0 || t.deleted == 0 || float64(t.deleted) <= t.opts.compactRatio*float64(t.count) {
+		return
+	}
+	if t.root != nil {
+		t.root = ins.rebuildSubtree(t.root)
+	}
+	t.deleted = 0
+}
+
+// Compact returns a new Tree holding the same items as t, rebuilt from scratch into a
+// Tree of minimal height with freshly allocated nodes in O(n) time.  The new Tree does not
+// share any nodes with t, so keeping it around will not keep nodes from older versions
+// of t alive, which makes it the right thing to hold on to for long-term retention.
+func (t *Tree[T]) Compact() *Tree[T] {
+	res := t.Fork()
+	ins := res.getNsp()
+	defer res.putNsp(ins)
+	if res.root != nil {
+		res.root = ins.rebuildSubtree(res.root)
+	}
+	res.deleted = 0
+	return res
+}
@@ -0,0 +1,64 @@
+package ibtree
+
+import "testing"
+
+func TestCompactAfter(t *testing.T) {
+	items := make([]int, 10000)
+	for i := range items {
+		items[i] = i
+	}
+	tree := NewWith[int](il, CompactAfter(4)).Insert(items...)
+	plain := New[int](il, items...)
+	tree, _ = tree.DeleteItems(items[:7000]...)
+	if tree.deleted != 7000 {
+		t.Fatalf("Expected 7000 deletions, got %d", tree.deleted)
+	}
+	tree, _ = tree.DeleteItems(items[7000:9801]...)
+	plain, _ = plain.DeleteItems(items[:9801]...)
+	if tree.Len() != 199 || plain.Len() != 199 {
+		t.Fatalf("Expected 199 items, got %d and %d", tree.Len(), plain.Len())
+	}
+	tree.root.balanced(t)
+	if tree.deleted != 0 || tree.root.h() != 8 {
+		t.Fatalf("Expected the Tree to have been compacted")
+	}
+	if plain.deleted != 9801 {
+		t.Fatalf("Expected 9801 deletions, got %d", plain.deleted)
+	}
+	compact := plain.Compact()
+	compact.root.balanced(t)
+	if compact.root.h() != 8 || compact.Len() != plain.Len() {
+		t.Fatalf("Expected a minimal height Tree, got height %d", compact.root.h())
+	}
+	a, b := compact.All(), plain.All()
+	for a.Next() && b.Next() {
+		if a.Item() != b.Item() {
+			t.Fatalf("Compact changed the items in the Tree")
+		}
+	}
+	seen := map[*node[int]]bool{}
+	for _, n := range collectNodes(plain.root, nil) {
+		seen[n] = true
+	}
+	for _, n := range collectNodes(compact.root, nil) {
+		if seen[n] {
+			t.Fatalf("Compact shares nodes with the original Tree")
+		}
+	}
+	if shrunk := New[int](il).Compact(); shrunk.Len() != 0 {
+		t.Fatalf("Compacting an empty Tree should leave it empty")
+	}
+	before := tree.deleted
+	tree, _, _ = tree.Delete(tree.root.i)
+	if tree.deleted != before+1 {
+		t.Fatalf("Delete should not compact the Tree")
+	}
+}
+
+// collectNodes appends every node in the subtree rooted at n to res.
+func collectNodes[T any](n *node[T], res []*node[T]) []*node[T] {
+	if n == nil {
+		return res
+	}
+	return collectNodes(n.r, append(collectNodes(n.l, res), n))
+}
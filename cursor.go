@@ -0,0 +1,62 @@
+package ibtree
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// The functions in this file implement keyset pagination.  A cursor token records
+// the last item a page ended with rather than how many items came before it, so
+// pages stay correct when items are inserted or deleted between requests, and a
+// token made from one version of a Tree can be resumed against any newer one.
+
+// EncodeCursor makes an opaque, URL-safe cursor token out of item, which should be
+// the last item a caller has seen.  enc turns item into bytes, and only needs to keep
+// enough of it to sort it correctly.
+func EncodeCursor[T any](item T, enc func(T) ([]byte, error)) (string, error) {
+	buf, err := enc(item)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// ResumeAfter returns an Iter over the items in t that come after the item token was made
+// from, whether or not that item is still in t.  dec must undo the enc that was passed to
+// EncodeCursor.  An empty token starts from the smallest item in t.
+func (t *Tree[T]) ResumeAfter(token string, dec func([]byte) (T, error)) (Iter[T], error) {
+	if token == "" {
+		return t.Iterator(nil, nil), nil
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor token: %w", err)
+	}
+	last, err := dec(buf)
+	if err != nil {
+		return nil, err
+	}
+	return t.Iterator(Lte(t.Cmp(last)), nil), nil
+}
+
+// Page returns up to limit items that come after the position token records, along with
+// the token for the page after this one.  next is empty once there are no more items.
+// See ResumeAfter and EncodeCursor for how tokens are made and read.
+func (t *Tree[T]) Page(token string, limit int, enc func(T) ([]byte, error), dec func([]byte) (T, error)) (items []T, next string, err error) {
+	iter, err := t.ResumeAfter(token, dec)
+	if err != nil {
+		return nil, "", err
+	}
+	defer iter.Release()
+	for len(items) < limit && iter.Next() {
+		items = append(items, iter.Item())
+	}
+	if len(items) == 0 {
+		return
+	}
+	if _, more := iter.Peek(); !more {
+		return
+	}
+	next, err = EncodeCursor(items[len(items)-1], enc)
+	return
+}
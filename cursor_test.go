@@ -0,0 +1,37 @@
+package ibtree
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestPage(t *testing.T) {
+	enc := func(i int) ([]byte, error) { return []byte(strconv.Itoa(i)), nil }
+	dec := func(b []byte) (int, error) { return strconv.Atoi(string(b)) }
+	tree := New[int](il)
+	for i := 0; i < 25; i++ {
+		tree = tree.Insert(i * 2)
+	}
+	items, token, err := tree.Page("", 10, enc, dec)
+	if err != nil || len(items) != 10 || items[9] != 18 || token == "" {
+		t.Fatalf("Unexpected first page %v %q %v", items, token, err)
+	}
+	// Items inserted before the cursor and deleting the last seen item must not disturb the next page.
+	tree, _, _ = tree.Insert(1, 3).Delete(18)
+	items, token, err = tree.Page(token, 10, enc, dec)
+	if err != nil || len(items) != 10 || items[0] != 20 || items[9] != 38 {
+		t.Fatalf("Unexpected second page %v %v", items, err)
+	}
+	items, token, err = tree.Page(token, 10, enc, dec)
+	if err != nil || len(items) != 5 || items[4] != 48 || token != "" {
+		t.Fatalf("Unexpected last page %v %q %v", items, token, err)
+	}
+	if _, _, err = tree.Page("!!!", 10, enc, dec); err == nil {
+		t.Fatalf("Expected an error for a malformed token")
+	}
+	bad := errors.New("bad")
+	if _, err = EncodeCursor(1, func(int) ([]byte, error) { return nil, bad }); !errors.Is(err, bad) {
+		t.Fatalf("Expected the encoding error, got %v", err)
+	}
+}
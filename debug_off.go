@@ -0,0 +1,17 @@
+//go:build !race && !debug
+
+package ibtree
+
+type writeGuard struct{}
+
+type stackGuard struct{}
+
+func (g *stackGuard) acquire() {}
+
+func (g *stackGuard) release() {}
+
+func nop() {}
+
+func (t *Tree[T]) enterWrite(*nodeStack[T]) func() { return nop }
+
+func (t *Tree[T]) checkRead() {}
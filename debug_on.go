@@ -0,0 +1,52 @@
+//go:build race || debug
+
+// The checks in this file are compiled in when ibtree is built with the race
+// detector or the debug build tag.  They catch misuse that would otherwise
+// silently corrupt a Tree, and panic close to the cause.
+
+package ibtree
+
+import "sync/atomic"
+
+// writeGuard tracks the writers currently changing a Tree.
+type writeGuard struct {
+	writers int32
+}
+
+// stackGuard tracks whether a nodeStack is checked out of its pool.
+type stackGuard struct {
+	live int32
+}
+
+func (g *stackGuard) acquire() {
+	atomic.StoreInt32(&g.live, 1)
+}
+
+func (g *stackGuard) release() {
+	if !atomic.CompareAndSwapInt32(&g.live, 1, 0) {
+		panic("ibtree: nodeStack returned to its pool twice")
+	}
+}
+
+// enterWrite must be called before a write to t through ins, and the returned
+// function must be called once the write is finished.
+func (t *Tree[T]) enterWrite(ins *nodeStack[T]) func() {
+	if atomic.LoadInt32(&ins.guard.live) == 0 {
+		panic("ibtree: Tree modified through a released nodeStack.  " +
+			"This usually means the function passed to a Fill or Erase was called after InsertWith, DeleteWith, or CreateWith returned.")
+	}
+	if atomic.AddInt32(&t.guard.writers, 1) != 1 {
+		atomic.AddInt32(&t.guard.writers, -1)
+		panic("ibtree: concurrent writers on the same Tree.  " +
+			"The function passed to a Fill or Erase must not be called from more than one goroutine at a time.")
+	}
+	return func() { atomic.AddInt32(&t.guard.writers, -1) }
+}
+
+// checkRead panics if t is being modified while it is read.
+func (t *Tree[T]) checkRead() {
+	if atomic.LoadInt32(&t.guard.writers) != 0 {
+		panic("ibtree: Tree iterated over while it is being modified.  " +
+			"This usually means the function passed to a Fill or Erase was kept and called after the Tree was returned.")
+	}
+}
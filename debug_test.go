@@ -0,0 +1,44 @@
+//go:build race || debug
+
+package ibtree
+
+import (
+	"strings"
+	"testing"
+)
+
+func expectPanic(t *testing.T, want string, fn func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		r := recover()
+		if msg, ok := r.(string); !ok || !strings.Contains(msg, want) {
+			t.Fatalf("Expected a panic mentioning %q, got %v", want, r)
+		}
+	}()
+	fn()
+}
+
+func TestMisuseDetection(t *testing.T) {
+	var kept func(int)
+	tree := New[int](il).InsertWith(func(f func(int)) {
+		f(1)
+		kept = f
+	})
+	expectPanic(t, "released nodeStack", func() { kept(2) })
+	tree = New[int](il, 1, 2, 3)
+	ns := tree.getNsp()
+	tree.putNsp(ns)
+	expectPanic(t, "returned to its pool twice", func() { tree.putNsp(ns) })
+	res := New[int](il)
+	ins := res.getNsp()
+	done := res.enterWrite(ins)
+	expectPanic(t, "being modified", func() { res.All().Next() })
+	expectPanic(t, "concurrent writers", func() { res.insertOne(ins, 1) })
+	done()
+	res.insertOne(ins, 1)
+	res.putNsp(ins)
+	if res.Len() != 1 {
+		t.Fatalf("Expected 1 item, got %d", res.Len())
+	}
+}
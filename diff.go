@@ -0,0 +1,427 @@
+package ibtree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const (
+	diffAdded = iota + 1
+	diffRemoved
+	diffChanged
+)
+
+// diffEntry is either a whole subtree that has not been examined yet, or
+// a single item that has been split out of its subtree.
+type diffEntry[T any] struct {
+	n    *node[T]
+	item bool
+}
+
+// differ walks two Trees in tandem, skipping over any subtrees that they share.
+// The top of each stack always holds the smallest unexamined part of its Tree.
+type differ[T any] struct {
+	less     LessThan[T]
+	eq       func(a, b T) bool
+	shared   func(*node[T])
+	from, to []diffEntry[T]
+}
+
+func newDiffer[T any](from, to *Tree[T], eq func(a, b T) bool) *differ[T] {
+	d := &differ[T]{less: to.less, eq: eq}
+	if from.root != nil {
+		d.from = append(d.from, diffEntry[T]{n: from.root})
+	}
+	if to.root != nil {
+		d.to = append(d.to, diffEntry[T]{n: to.root})
+	}
+	return d
+}
+
+// expand replaces the subtree at the top of s with its left subtree, its item,
+// and its right subtree.
+func expand[T any](s []diffEntry[T]) []diffEntry[T] {
+	n := s[len(s)-1].n
+	s = s[:len(s)-1]
+	if n.r != nil {
+		s = append(s, diffEntry[T]{n: n.r})
+	}
+	s = append(s, diffEntry[T]{n: n, item: true})
+	if n.l != nil {
+		s = append(s, diffEntry[T]{n: n.l})
+	}
+	return s
+}
+
+// next returns the next difference between the two Trees in ascending order.
+// ok will be false once there are no more differences.
+func (d *differ[T]) next() (kind int, from, to T, ok bool) {
+	for {
+		lf, lt := len(d.from), len(d.to)
+		if lf == 0 && lt == 0 {
+			return
+		}
+		if lf == 0 || lt == 0 {
+			s := &d.from
+			if lf == 0 {
+				s = &d.to
+			}
+			if top := (*s)[len(*s)-1]; !top.item {
+				*s = expand(*s)
+				continue
+			}
+			n := (*s)[len(*s)-1].n
+			*s = (*s)[:len(*s)-1]
+			if lf == 0 {
+				return diffAdded, from, n.i, true
+			}
+			return diffRemoved, n.i, to, true
+		}
+		a, b := d.from[lf-1], d.to[lt-1]
+		switch {
+		case !a.item && !b.item:
+			if a.n == b.n {
+				d.from, d.to = d.from[:lf-1], d.to[:lt-1]
+				if d.shared != nil {
+					d.shared(a.n)
+				}
+			} else if a.n.h() >= b.n.h() {
+				d.from = expand(d.from)
+			} else {
+				d.to = expand(d.to)
+			}
+		case !a.item:
+			d.from = expand(d.from)
+		case !b.item:
+			d.to = expand(d.to)
+		case d.less(a.n.i, b.n.i):
+			d.from = d.from[:lf-1]
+			return diffRemoved, a.n.i, to, true
+		case d.less(b.n.i, a.n.i):
+			d.to = d.to[:lt-1]
+			return diffAdded, from, b.n.i, true
+		default:
+			d.from, d.to = d.from[:lf-1], d.to[:lt-1]
+			if a.n != b.n && !d.eq(a.n.i, b.n.i) {
+				return diffChanged, a.n.i, b.n.i, true
+			}
+		}
+	}
+}
+
+// DiffRenderer renders the differences between two versions of a Tree as a
+// unified-diff style text report.  Both versions must be ordered the same way.
+type DiffRenderer[T any] struct {
+	// Format renders a single item.  If nil, fmt.Sprint is used.
+	Format func(T) string
+	// Equal decides whether two items that sort the same are unchanged.
+	// If nil, they are unchanged if Format renders them identically.
+	Equal func(a, b T) bool
+	// Shared adds a line for every subtree the two versions share.
+	Shared bool
+}
+
+// Render writes the differences between from and to into w.  Removed items are prefixed with -,
+// added items are prefixed with +, and changed items are written as a removal of the old item
+// followed by an addition of the new one.  Shared subtrees are not examined at all, and
+// are reported with a leading = if d.Shared is set.
+func (d *DiffRenderer[T]) Render(w io.Writer, from, to *Tree[T]) error {
+	format := d.Format
+	if format == nil {
+		format = func(v T) string { return fmt.Sprint(v) }
+	}
+	eq := d.Equal
+	if eq == nil {
+		eq = func(a, b T) bool { return format(a) == format(b) }
+	}
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "--- from (%d items)\n+++ to (%d items)\n", from.Len(), to.Len())
+	df := newDiffer(from, to, eq)
+	if d.Shared {
+		df.shared = func(n *node[T]) {
+			fmt.Fprintf(out, "= shared subtree %s .. %s (height %d)\n", format(min(n).i), format(max(n).i), n.h())
+		}
+	}
+	var added, removed, changed int
+	for {
+		kind, a, b, ok := df.next()
+		if !ok {
+			break
+		}
+		switch kind {
+		case diffAdded:
+			added++
+			fmt.Fprintf(out, "+%s\n", format(b))
+		case diffRemoved:
+			removed++
+			fmt.Fprintf(out, "-%s\n", format(a))
+		case diffChanged:
+			changed++
+			fmt.Fprintf(out, "-%s\n+%s\n", format(a), format(b))
+		}
+	}
+	fmt.Fprintf(out, "@@ %d added, %d removed, %d changed @@\n", added, removed, changed)
+	return out.Flush()
+}
+
+// Diff compares t with other, which must be ordered the same way, and returns the items
+// that are only in other, the items that are only in t, and the pairs of items that sort
+// the same but that eq says are different.  The First item in each changed Pair comes from
+// t, and the Second from other.  All three slices are in ascending order.
+//
+// Subtrees that t and other share are skipped by pointer without being examined, so when
+// one was derived from the other, Diff only does work proportional to what changed.
+func (t *Tree[T]) Diff(other *Tree[T], eq func(a, b T) bool) (added, removed []T, changed []Pair[T, T]) {
+	iter := t.DiffIterator(other, eq)
+	for iter.Next() {
+		switch c := iter.Change(); c.Kind {
+		case ChangeAdded:
+			added = append(added, c.New)
+		case ChangeRemoved:
+			removed = append(removed, c.Old)
+		case ChangeModified:
+			changed = append(changed, MakePair(c.Old, c.New))
+		}
+	}
+	return
+}
+
+// Equal returns whether t and other, which must be ordered the same way, hold the same items.
+// Items that sort the same are compared with eq.  Equal returns false as soon as it finds a
+// difference, and skips subtrees that t and other share without comparing their items.
+func (t *Tree[T]) Equal(other *Tree[T], eq func(a, b T) bool) bool {
+	if t.count != other.count {
+		return false
+	}
+	if t.root == other.root {
+		return true
+	}
+	_, _, _, found := newDiffer(t, other, eq).next()
+	return !found
+}
+
+// IsSubset returns whether every item in t has an equal item in other, which must be
+// ordered the same way.  Both Trees are walked in tandem, subtrees they share are skipped,
+// and IsSubset returns as soon as it finds an item that is only in t.
+func (t *Tree[T]) IsSubset(other *Tree[T]) bool {
+	if t.count > other.count {
+		return false
+	}
+	d := newDiffer(t, other, func(a, b T) bool { return true })
+	for {
+		kind, _, _, ok := d.next()
+		if !ok {
+			return true
+		}
+		if kind == diffRemoved {
+			return false
+		}
+	}
+}
+
+// IsSuperset returns whether t has an equal item for every item in other.
+// It is the same as other.IsSubset(t).
+func (t *Tree[T]) IsSuperset(other *Tree[T]) bool {
+	return other.IsSubset(t)
+}
+
+// overlapEntry is a diffEntry along with the smallest and largest items in it.
+type overlapEntry[T any] struct {
+	diffEntry[T]
+	lo, hi T
+}
+
+// expandOverlap is expand for overlapEntries.
+func expandOverlap[T any](s []overlapEntry[T]) []overlapEntry[T] {
+	e := s[len(s)-1]
+	n := e.n
+	s = s[:len(s)-1]
+	if n.r != nil {
+		s = append(s, overlapEntry[T]{diffEntry: diffEntry[T]{n: n.r}, lo: min(n.r).i, hi: e.hi})
+	}
+	s = append(s, overlapEntry[T]{diffEntry: diffEntry[T]{n: n, item: true}, lo: n.i, hi: n.i})
+	if n.l != nil {
+		s = append(s, overlapEntry[T]{diffEntry: diffEntry[T]{n: n.l}, lo: e.lo, hi: max(n.l).i})
+	}
+	return s
+}
+
+// Overlaps returns whether t and other, which must be ordered the same way, have at least
+// one item in common.  Both Trees are walked in tandem the same way IsSubset walks them, but
+// every unexamined subtree also carries its smallest and largest items, so a subtree that lies
+// entirely before the rest of the other Tree is skipped without being looked into.  Subtrees
+// that t and other share are never looked into either, and Overlaps returns on the first match.
+func (t *Tree[T]) Overlaps(other *Tree[T]) bool {
+	if t.root == nil || other.root == nil {
+		return false
+	}
+	a := []overlapEntry[T]{{diffEntry: diffEntry[T]{n: t.root}, lo: min(t.root).i, hi: max(t.root).i}}
+	b := []overlapEntry[T]{{diffEntry: diffEntry[T]{n: other.root}, lo: min(other.root).i, hi: max(other.root).i}}
+	for len(a) > 0 && len(b) > 0 {
+		x, y := a[len(a)-1], b[len(b)-1]
+		switch {
+		case !x.item && !y.item && x.n == y.n:
+			return true
+		case t.less(x.hi, y.lo):
+			a = a[:len(a)-1]
+		case t.less(y.hi, x.lo):
+			b = b[:len(b)-1]
+		case x.item && y.item:
+			return true
+		case !x.item && (y.item || x.n.h() >= y.n.h()):
+			a = expandOverlap(a)
+		default:
+			b = expandOverlap(b)
+		}
+	}
+	return false
+}
+
+// ChangeKind is the kind of difference a Change describes.
+type ChangeKind int
+
+const (
+	// ChangeAdded is an item that is only in the newer Tree.
+	ChangeAdded ChangeKind = diffAdded
+	// ChangeRemoved is an item that is only in the older Tree.
+	ChangeRemoved ChangeKind = diffRemoved
+	// ChangeModified is an item that is in both Trees, but has a different value in each.
+	ChangeModified ChangeKind = diffChanged
+)
+
+// Change is a single difference between two versions of a Tree.
+// Old is the zero value for ChangeAdded, and New is the zero value for ChangeRemoved.
+type Change[T any] struct {
+	Kind     ChangeKind
+	Old, New T
+}
+
+// DiffIter lazily yields the differences between two versions of a Tree in ascending order.
+type DiffIter[T any] struct {
+	d      *differ[T]
+	change Change[T]
+	ok     bool
+}
+
+// Next moves to the next difference.  It returns false once there are no more.
+func (i *DiffIter[T]) Next() bool {
+	if i.d != nil {
+		var kind int
+		kind, i.change.Old, i.change.New, i.ok = i.d.next()
+		i.change.Kind = ChangeKind(kind)
+		if i.ok {
+			return true
+		}
+	}
+	i.Release()
+	return false
+}
+
+// Change returns the current difference, assuming the previous call to Next returned true.
+// It will panic otherwise.
+func (i *DiffIter[T]) Change() Change[T] {
+	if !i.ok {
+		panic("No iteration in progress")
+	}
+	return i.change
+}
+
+// Release releases all the state the DiffIter holds.  Next will return false afterwards.
+func (i *DiffIter[T]) Release() {
+	i.d, i.change, i.ok = nil, Change[T]{}, false
+}
+
+// DiffIterator returns a DiffIter that streams the differences between t and other, which
+// must be ordered the same way.  It reports the same differences that Diff does, but only
+// does the work needed to find each one as Next is called, which makes it suitable for
+// feeding a changefeed from large Trees.  Subtrees shared by t and other are skipped by pointer.
+func (t *Tree[T]) DiffIterator(other *Tree[T], eq func(a, b T) bool) *DiffIter[T] {
+	return &DiffIter[T]{d: newDiffer(t, other, eq)}
+}
+
+// sinceIter yields the items in a Tree that are not shared with an earlier version of it.
+type sinceIter[T any] struct {
+	d    *differ[T]
+	item T
+	ok   bool
+}
+
+func (s *sinceIter[T]) Release() {
+	var ref T
+	s.d, s.item, s.ok = nil, ref, false
+}
+
+func (s *sinceIter[T]) Next() bool {
+	for s.d != nil {
+		kind, _, item, ok := s.d.next()
+		if !ok {
+			break
+		}
+		if kind != diffRemoved {
+			s.item, s.ok = item, true
+			return true
+		}
+	}
+	s.Release()
+	return false
+}
+
+func (s *sinceIter[T]) Prev() bool {
+	return false
+}
+
+// Rebase is not supported by the Iter NewSince returns.
+func (s *sinceIter[T]) Rebase(*Tree[T]) bool {
+	return false
+}
+
+// Clone returns a copy of the sinceIter that walks the two Trees on its own.
+func (s *sinceIter[T]) Clone() Iter[T] {
+	res := *s
+	if s.d != nil {
+		d := *s.d
+		d.from = append([]diffEntry[T](nil), d.from...)
+		d.to = append([]diffEntry[T](nil), d.to...)
+		res.d = &d
+	}
+	return &res
+}
+
+// Peek returns the next item without moving the sinceIter.
+func (s *sinceIter[T]) Peek() (T, bool) {
+	return peekClone[T](s)
+}
+
+// Reset is not supported by the Iter NewSince returns.
+func (s *sinceIter[T]) Reset(*Tree[T], Test[T], Test[T]) bool {
+	return false
+}
+
+// Seek is not supported by the Iter NewSince returns.
+func (s *sinceIter[T]) Seek(CompareAgainst[T]) bool {
+	return false
+}
+
+func (s *sinceIter[T]) Item() T {
+	if !s.ok {
+		panic("No iteration in progress")
+	}
+	return s.item
+}
+
+// NewSince returns an Iter that yields, in ascending order, the items in t that live in nodes
+// t does not share with parent.  Subtrees shared with parent are skipped without being examined,
+// so when t was derived from parent the cost is proportional to the number of changes rather than
+// the size of t.
+//
+// Every item that was inserted or replaced since parent will be yielded.  Since nodes
+// are copied all the way up to the root whenever anything beneath them changes, items whose nodes
+// were merely copied along the way are yielded as well.  Use a DiffRenderer with an Equal
+// function if you need an exact list of changes.  Items that were deleted are not yielded.
+//
+// The Iter returned by NewSince cannot run backwards -- the
+// Prev() method will always return false.
+func (t *Tree[T]) NewSince(parent *Tree[T]) Iter[T] {
+	return &sinceIter[T]{d: newDiffer(parent, t, func(a, b T) bool { return false })}
+}
@@ -0,0 +1,290 @@
+package ibtree
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderDiff(t *testing.T) {
+	from := CreateWith[ovr](ol, func(f func(ovr)) {
+		for i := 0; i < 1000; i++ {
+			f(ovr{i: i})
+		}
+	})
+	to := from.Insert(ovr{i: 1000}, ovr{i: 500, mark: 1})
+	to, _, _ = to.Delete(ovr{i: 3})
+	buf := &strings.Builder{}
+	r := &DiffRenderer[ovr]{Format: func(o ovr) string { return fmt.Sprintf("%d:%d", o.i, o.mark) }}
+	if err := r.Render(buf, from, to); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expect := `--- from (1000 items)
++++ to (1000 items)
+-3:0
+-500:0
++500:1
++1000:0
+@@ 1 added, 1 removed, 1 changed @@
+`
+	if buf.String() != expect {
+		t.Fatalf("Expected\n%s\ngot\n%s", expect, buf.String())
+	}
+	buf.Reset()
+	r.Shared = true
+	if err := r.Render(buf, from, to); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !strings.Contains(buf.String(), "= shared subtree") {
+		t.Fatalf("Expected shared subtrees in\n%s", buf.String())
+	}
+	buf.Reset()
+	r = &DiffRenderer[ovr]{}
+	if err := r.Render(buf, New[ovr](ol, ovr{i: 1}), New[ovr](ol)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !strings.Contains(buf.String(), "\n-{1 0}\n") {
+		t.Fatalf("Expected a removal in\n%s", buf.String())
+	}
+}
+
+func TestDifferRandom(t *testing.T) {
+	src := rand.New(rand.NewSource(3))
+	from := New[ovr](ol)
+	for i := 0; i < 2000; i++ {
+		from = from.Insert(ovr{i: src.Intn(3000)})
+	}
+	to := from
+	for i := 0; i < 200; i++ {
+		switch src.Intn(3) {
+		case 0:
+			to, _, _ = to.Delete(ovr{i: src.Intn(3000)})
+		default:
+			to = to.Insert(ovr{i: src.Intn(3000), mark: src.Intn(2)})
+		}
+	}
+	expect := map[int][2]int{}
+	from.Walk(func(o ovr) bool { expect[o.i] = [2]int{o.mark + 1, 0}; return true })
+	to.Walk(func(o ovr) bool {
+		e := expect[o.i]
+		e[1] = o.mark + 1
+		expect[o.i] = e
+		return true
+	})
+	df := newDiffer(from, to, func(a, b ovr) bool { return a == b })
+	last := -1
+	for {
+		kind, a, b, ok := df.next()
+		if !ok {
+			break
+		}
+		key := a.i
+		if kind == diffAdded {
+			key = b.i
+		}
+		if key <= last {
+			t.Fatalf("Diff out of order at %d", key)
+		}
+		last = key
+		e := expect[key]
+		switch {
+		case kind == diffAdded && e[0] == 0 && e[1] == b.mark+1:
+		case kind == diffRemoved && e[1] == 0 && e[0] == a.mark+1:
+		case kind == diffChanged && e[0] == a.mark+1 && e[1] == b.mark+1 && e[0] != e[1]:
+		default:
+			t.Fatalf("Unexpected diff %d %v %v, expected %v", kind, a, b, e)
+		}
+		delete(expect, key)
+	}
+	for k, e := range expect {
+		if e[0] != e[1] {
+			t.Fatalf("Missed difference at %d: %v", k, e)
+		}
+	}
+}
+
+func TestNewSince(t *testing.T) {
+	parent := CreateWith[int](il, func(f func(int)) {
+		for i := 0; i < 10000; i += 2 {
+			f(i)
+		}
+	})
+	child := parent.Insert(101, 5001, 9001)
+	child, _, _ = child.Delete(2000)
+	seen := map[int]bool{}
+	iter := child.NewSince(parent)
+	last := -1
+	for iter.Next() {
+		if iter.Item() <= last {
+			t.Fatalf("NewSince out of order at %d", iter.Item())
+		}
+		last = iter.Item()
+		seen[last] = true
+	}
+	for _, i := range []int{101, 5001, 9001} {
+		if !seen[i] {
+			t.Fatalf("NewSince did not yield %d", i)
+		}
+	}
+	if seen[2000] {
+		t.Fatalf("NewSince yielded a deleted item")
+	}
+	if len(seen) > 100 {
+		t.Fatalf("NewSince yielded %d items, it should not have examined shared subtrees", len(seen))
+	}
+	if iter.Prev() || iter.Next() {
+		t.Fatalf("Iteration should be over")
+	}
+	if iter = parent.NewSince(parent); iter.Next() {
+		t.Fatalf("A tree has nothing new since itself")
+	}
+}
+
+func TestTreeDiff(t *testing.T) {
+	base := New[ovr](ol)
+	for i := 0; i < 1000; i++ {
+		base = base.Insert(ovr{i, 0})
+	}
+	next := base.Insert(ovr{5, 1}, ovr{2000, 0}, ovr{-1, 0}, ovr{500, 0})
+	next, _, _ = next.Delete(ovr{i: 700})
+	next, _, _ = next.Delete(ovr{i: 10})
+	added, removed, changed := base.Diff(next, func(a, b ovr) bool { return a == b })
+	if !reflect.DeepEqual(added, []ovr{{-1, 0}, {2000, 0}}) {
+		t.Fatalf("Unexpected additions %v", added)
+	}
+	if !reflect.DeepEqual(removed, []ovr{{10, 0}, {700, 0}}) {
+		t.Fatalf("Unexpected removals %v", removed)
+	}
+	if !reflect.DeepEqual(changed, []Pair[ovr, ovr]{MakePair(ovr{5, 0}, ovr{5, 1})}) {
+		t.Fatalf("Unexpected changes %v", changed)
+	}
+	added, removed, changed = base.Diff(base.Fork(), func(a, b ovr) bool {
+		t.Fatalf("eq called on identical Trees")
+		return true
+	})
+	if added != nil || removed != nil || changed != nil {
+		t.Fatalf("Expected no differences")
+	}
+}
+
+func TestDiffIterator(t *testing.T) {
+	base := New[ovr](ol)
+	for i := 0; i < 200; i++ {
+		base = base.Insert(ovr{i, 0})
+	}
+	next := base.Insert(ovr{3, 1}, ovr{300, 0})
+	next, _, _ = next.Delete(ovr{i: 100})
+	iter := base.DiffIterator(next, func(a, b ovr) bool { return a == b })
+	var got []Change[ovr]
+	for iter.Next() {
+		got = append(got, iter.Change())
+	}
+	expect := []Change[ovr]{
+		{Kind: ChangeModified, Old: ovr{3, 0}, New: ovr{3, 1}},
+		{Kind: ChangeRemoved, Old: ovr{100, 0}},
+		{Kind: ChangeAdded, New: ovr{300, 0}},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("Expected %v, got %v", expect, got)
+	}
+	if iter.Next() {
+		t.Fatalf("Exhausted DiffIter should stay exhausted")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Expected Change to panic after iteration finished")
+		}
+	}()
+	iter.Change()
+}
+
+func TestTreeEqual(t *testing.T) {
+	eq := func(a, b ovr) bool { return a == b }
+	a := New[ovr](ol)
+	for i := 0; i < 500; i++ {
+		a = a.Insert(ovr{i, 0})
+	}
+	calls := 0
+	counting := func(x, y ovr) bool {
+		calls++
+		return x == y
+	}
+	if !a.Equal(a.Fork(), counting) || calls != 0 {
+		t.Fatalf("A Fork should be equal without comparing items")
+	}
+	b := a.Insert(ovr{250, 0})
+	if !a.Equal(b, counting) || calls == 0 || calls > 20 {
+		t.Fatalf("Expected an equal Tree after %d comparisons", calls)
+	}
+	rebuilt := New[ovr](ol)
+	for i := 499; i >= 0; i-- {
+		rebuilt = rebuilt.Insert(ovr{i, 0})
+	}
+	if !a.Equal(rebuilt, eq) {
+		t.Fatalf("Expected Trees built separately to be equal")
+	}
+	if a.Equal(a.Insert(ovr{7, 1}), eq) || a.Equal(a.Insert(ovr{1000, 0}), eq) {
+		t.Fatalf("Expected changed Trees to differ")
+	}
+	shrunk, _, _ := a.Delete(ovr{i: 3})
+	if a.Equal(shrunk.Insert(ovr{1000, 0}), eq) {
+		t.Fatalf("Expected Trees with different items to differ")
+	}
+}
+
+func TestIsSubset(t *testing.T) {
+	base := New[int](il, rand.Perm(1000)...)
+	grown := base.Insert(2000, 3000)
+	if !base.IsSubset(grown) || !grown.IsSuperset(base) || grown.IsSubset(base) || base.IsSuperset(grown) {
+		t.Fatalf("A Tree should be a subset of a Tree it was extended into")
+	}
+	if !base.IsSubset(base) || !New[int](il).IsSubset(base) || base.IsSubset(New[int](il)) {
+		t.Fatalf("Subset edge cases are wrong")
+	}
+	swapped, _, _ := grown.Delete(500)
+	if base.IsSubset(swapped) || !swapped.IsSuperset(New[int](il, 1, 2, 3, 2000)) {
+		t.Fatalf("Expected a missing item to break the subset")
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	low := New[int](il, 1, 2, 3, 4, 5)
+	high := New[int](il, 10, 11, 12, 13, 14, 15, 16)
+	if low.Overlaps(high) || high.Overlaps(low) || low.Overlaps(New[int](il)) {
+		t.Fatalf("Disjoint Trees should not overlap")
+	}
+	mixed := New[int](il, 0, 6, 8, 9, 12)
+	if !mixed.Overlaps(high) || !high.Overlaps(mixed) || mixed.Overlaps(low) {
+		t.Fatalf("Expected overlaps to be found")
+	}
+	if !low.Overlaps(low.Fork()) {
+		t.Fatalf("A Tree should overlap itself")
+	}
+	src := rand.New(rand.NewSource(13))
+	for round := 0; round < 200; round++ {
+		a, b := New[int](il), New[int](il)
+		seen, want := map[int]bool{}, false
+		for _, v := range src.Perm(400)[:src.Intn(40)+1] {
+			a, seen[v] = a.Insert(v), true
+		}
+		for _, v := range src.Perm(400)[:src.Intn(200)+1] {
+			b, want = b.Insert(v), want || seen[v]
+		}
+		if a.Overlaps(b) != want || b.Overlaps(a) != want {
+			t.Fatalf("Round %d: expected Overlaps to be %v", round, want)
+		}
+		if c := b.Insert(1000); !c.Overlaps(b) || b.Overlaps(New[int](il, 1000)) {
+			t.Fatalf("Round %d: derived Trees should overlap", round)
+		}
+	}
+	stats := &LookupStats{}
+	counted := NewWith[int](il, CollectLookups(stats)).Insert(1, 2, 3, 20, 30)
+	if !counted.Overlaps(New[int](il, 4, 5, 30)) {
+		t.Fatalf("Expected 30 to be found")
+	}
+	if snap := stats.Snapshot(); len(snap.Hits) != 0 || len(snap.Misses) != 0 {
+		t.Fatalf("Overlaps should not record lookups")
+	}
+}
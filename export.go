@@ -0,0 +1,87 @@
+package ibtree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// exportNDJSON writes every item iter yields to w as newline-delimited records.
+func exportNDJSON[T any](w io.Writer, iter Iter[T], enc func(T) ([]byte, error)) error {
+	defer iter.Release()
+	out := bufio.NewWriter(w)
+	for iter.Next() {
+		buf, err := enc(iter.Item())
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(buf, '\n') != -1 {
+			return fmt.Errorf("ibtree: encoded item %q contains a newline", buf)
+		}
+		if _, err = out.Write(buf); err != nil {
+			return err
+		}
+		if err = out.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// ExportNDJSON writes every item in the Tree to w in sorted order as newline-delimited JSON.
+// enc is called once per item to encode it, and must not produce any newlines.
+// Items are streamed straight out of the Tree, so ExportNDJSON needs no more memory than
+// an Iter and a small write buffer.
+func (t *Tree[T]) ExportNDJSON(w io.Writer, enc func(T) ([]byte, error)) error {
+	return exportNDJSON(w, t.All(), enc)
+}
+
+// WriteRange is ExportNDJSON for just the items in a range.  It writes the items that
+// t.Iterator(start, stop) would yield to w in sorted order, using the same framing as ExportNDJSON,
+// so the result can be read back with ImportNDJSON.
+func (t *Tree[T]) WriteRange(w io.Writer, start, stop Test[T], enc func(T) ([]byte, error)) error {
+	return exportNDJSON(w, t.Iterator(start, stop), enc)
+}
+
+// ExportCSV writes every item in the Tree to w in sorted order as CSV records.
+// If header is not empty, it is written as the first record.  enc is called
+// once per item to turn it into the fields of a record.
+func (t *Tree[T]) ExportCSV(w io.Writer, header []string, enc func(T) ([]string, error)) error {
+	out := csv.NewWriter(w)
+	if len(header) > 0 {
+		if err := out.Write(header); err != nil {
+			return err
+		}
+	}
+	iter := t.All()
+	defer iter.Release()
+	for iter.Next() {
+		rec, err := enc(iter.Item())
+		if err != nil {
+			return err
+		}
+		if err = out.Write(rec); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// AppendTo appends every item in the Tree to buf in sorted order and returns the extended
+// slice.  Since the number of items is known up front, buf is grown at most once.
+func (t *Tree[T]) AppendTo(buf []T) []T {
+	if need := len(buf) + t.count; cap(buf) < need {
+		grown := make([]T, len(buf), need)
+		copy(grown, buf)
+		buf = grown
+	}
+	return appendItems(buf, t.root)
+}
+
+// Items returns a new slice holding every item in the Tree in sorted order.
+func (t *Tree[T]) Items() []T {
+	return t.AppendTo(make([]T, 0, t.count))
+}
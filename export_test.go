@@ -0,0 +1,86 @@
+package ibtree
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type kv struct {
+	Key string `json:"key"`
+	Val int    `json:"val"`
+}
+
+func kvl(a, b kv) bool { return a.Key < b.Key }
+
+func TestExportNDJSON(t *testing.T) {
+	tree := New[kv](kvl, kv{"b", 2}, kv{"a", 1}, kv{"c", 3})
+	buf := &strings.Builder{}
+	if err := tree.ExportNDJSON(buf, func(v kv) ([]byte, error) { return json.Marshal(v) }); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expect := "{\"key\":\"a\",\"val\":1}\n{\"key\":\"b\",\"val\":2}\n{\"key\":\"c\",\"val\":3}\n"
+	if buf.String() != expect {
+		t.Fatalf("Expected\n%s\ngot\n%s", expect, buf.String())
+	}
+	if err := tree.ExportNDJSON(buf, func(v kv) ([]byte, error) { return []byte("a\nb"), nil }); err == nil {
+		t.Fatalf("Expected an error for a multiline record")
+	}
+	boom := errors.New("boom")
+	if err := tree.ExportNDJSON(buf, func(v kv) ([]byte, error) { return nil, boom }); err != boom {
+		t.Fatalf("Expected boom, got %v", err)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	tree := New[kv](kvl, kv{"b,x", 2}, kv{"a", 1})
+	buf := &strings.Builder{}
+	err := tree.ExportCSV(buf, []string{"key", "val"}, func(v kv) ([]string, error) {
+		return []string{v.Key, strconv.Itoa(v.Val)}, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expect := "key,val\na,1\n\"b,x\",2\n"
+	if buf.String() != expect {
+		t.Fatalf("Expected\n%s\ngot\n%s", expect, buf.String())
+	}
+}
+
+func TestWriteRange(t *testing.T) {
+	tree := New[kv](kvl, kv{"t1/a", 1}, kv{"t1/b", 2}, kv{"t2/a", 3}, kv{"t2/b", 4}, kv{"t3/a", 5})
+	buf := &strings.Builder{}
+	enc := func(v kv) ([]byte, error) { return json.Marshal(v) }
+	err := tree.WriteRange(buf, Lt(tree.Cmp(kv{Key: "t2/"})), Gte(tree.Cmp(kv{Key: "t3/"})), enc)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expect := "{\"key\":\"t2/a\",\"val\":3}\n{\"key\":\"t2/b\",\"val\":4}\n"
+	if buf.String() != expect {
+		t.Fatalf("Expected\n%s\ngot\n%s", expect, buf.String())
+	}
+}
+
+func TestItems(t *testing.T) {
+	tree := New[int](il, rand.Perm(100)...)
+	items := tree.Items()
+	if len(items) != 100 || cap(items) != 100 {
+		t.Fatalf("Expected exactly 100 items, got %d with capacity %d", len(items), cap(items))
+	}
+	for i := range items {
+		if items[i] != i {
+			t.Fatalf("Items out of order: %v", items)
+		}
+	}
+	buf := append(make([]int, 0, 200), -2, -1)
+	res := tree.AppendTo(buf)
+	if len(res) != 102 || &res[0] != &buf[:1][0] || res[0] != -2 || res[101] != 99 {
+		t.Fatalf("AppendTo did not append into the buffer: %v", res)
+	}
+	if res = New[int](il).AppendTo(nil); len(res) != 0 {
+		t.Fatalf("Expected nothing from an empty Tree")
+	}
+}
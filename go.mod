@@ -1,3 +1,3 @@
 module github.com/VictorLowther/ibtree
 
-go 1.18
+go 1.23
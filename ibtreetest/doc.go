@@ -0,0 +1,4 @@
+// Package ibtreetest provides helpers for testing code that uses ibtree.
+//
+// Copyright 2022 Victor Lowther and RackN, Inc.
+package ibtreetest
@@ -0,0 +1,137 @@
+package ibtreetest
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/VictorLowther/ibtree"
+)
+
+// Shadow wraps an ibtree.Tree and mirrors every operation performed on it into a
+// reference implementation backed by a sorted slice.  After every operation, the results
+// of the operation and the full contents of the Tree are checked against the reference,
+// and any discrepancy fails the test.
+//
+// Like the Tree it wraps, a Shadow is immutable.  Every operation returns a new Shadow,
+// and calling Check on an older Shadow verifies that later operations did not leak into it.
+type Shadow[T any] struct {
+	tb   testing.TB
+	less ibtree.LessThan[T]
+	eq   func(a, b T) bool
+	tree *ibtree.Tree[T]
+	ref  []T
+}
+
+// NewShadow creates a new empty Shadow.  lt orders the items, and eq is used to check
+// that the items the Tree holds are the ones the reference holds.
+func NewShadow[T any](tb testing.TB, lt ibtree.LessThan[T], eq func(a, b T) bool) *Shadow[T] {
+	return &Shadow[T]{tb: tb, less: lt, eq: eq, tree: ibtree.New[T](lt)}
+}
+
+// Tree returns the Tree the Shadow wraps.
+func (s *Shadow[T]) Tree() *ibtree.Tree[T] {
+	return s.tree
+}
+
+// Len returns the number of items in the Shadow.
+func (s *Shadow[T]) Len() int {
+	return len(s.ref)
+}
+
+// search returns the position of item in the reference and whether it is there.
+func (s *Shadow[T]) search(item T) (int, bool) {
+	idx := sort.Search(len(s.ref), func(i int) bool { return !s.less(s.ref[i], item) })
+	return idx, idx < len(s.ref) && !s.less(item, s.ref[idx])
+}
+
+func (s *Shadow[T]) derive(tree *ibtree.Tree[T]) *Shadow[T] {
+	ref := make([]T, len(s.ref))
+	copy(ref, s.ref)
+	return &Shadow[T]{tb: s.tb, less: s.less, eq: s.eq, tree: tree, ref: ref}
+}
+
+// Insert inserts items into both the Tree and the reference.
+func (s *Shadow[T]) Insert(items ...T) *Shadow[T] {
+	s.tb.Helper()
+	res := s.derive(s.tree.Insert(items...))
+	for _, item := range items {
+		idx, found := res.search(item)
+		if found {
+			res.ref[idx] = item
+			continue
+		}
+		var zero T
+		res.ref = append(res.ref, zero)
+		copy(res.ref[idx+1:], res.ref[idx:])
+		res.ref[idx] = item
+	}
+	res.Check()
+	return res
+}
+
+// Delete deletes item from both the Tree and the reference, and checks that
+// both agree on what was deleted.
+func (s *Shadow[T]) Delete(item T) *Shadow[T] {
+	s.tb.Helper()
+	tree, deleted, found := s.tree.Delete(item)
+	res := s.derive(tree)
+	idx, refFound := res.search(item)
+	if found != refFound {
+		s.tb.Fatalf("Delete(%v): tree found %v, reference found %v", item, found, refFound)
+	}
+	if found {
+		if !s.eq(deleted, res.ref[idx]) {
+			s.tb.Fatalf("Delete(%v): tree deleted %v, reference deleted %v", item, deleted, res.ref[idx])
+		}
+		res.ref = append(res.ref[:idx], res.ref[idx+1:]...)
+	}
+	res.Check()
+	return res
+}
+
+// Fetch looks item up in both the Tree and the reference, and checks that they agree.
+func (s *Shadow[T]) Fetch(item T) (T, bool) {
+	s.tb.Helper()
+	got, found := s.tree.Fetch(item)
+	idx, refFound := s.search(item)
+	if found != refFound {
+		s.tb.Fatalf("Fetch(%v): tree found %v, reference found %v", item, found, refFound)
+	}
+	if found && !s.eq(got, s.ref[idx]) {
+		s.tb.Fatalf("Fetch(%v): tree returned %v, reference returned %v", item, got, s.ref[idx])
+	}
+	return got, found
+}
+
+// Check verifies that the Tree holds exactly the same items as the reference, in
+// the same order, whether iterated forwards or backwards.
+func (s *Shadow[T]) Check() {
+	s.tb.Helper()
+	if s.tree.Len() != len(s.ref) {
+		s.tb.Fatalf("Tree has %d items, reference has %d", s.tree.Len(), len(s.ref))
+	}
+	idx := 0
+	iter := s.tree.Iterator(nil, nil)
+	for iter.Next() {
+		if idx >= len(s.ref) {
+			s.tb.Fatalf("Tree has more items than the reference")
+		}
+		if !s.eq(iter.Item(), s.ref[idx]) {
+			s.tb.Fatalf("Item %d: tree has %v, reference has %v", idx, iter.Item(), s.ref[idx])
+		}
+		idx++
+	}
+	if idx != len(s.ref) {
+		s.tb.Fatalf("Tree iteration stopped at %d of %d items", idx, len(s.ref))
+	}
+	iter = s.tree.Iterator(nil, nil)
+	for iter.Prev() {
+		idx--
+		if idx < 0 || !s.eq(iter.Item(), s.ref[idx]) {
+			s.tb.Fatalf("Reverse iteration diverged from the reference at item %d", idx)
+		}
+	}
+	if idx != 0 {
+		s.tb.Fatalf("Reverse iteration stopped at %d", idx)
+	}
+}
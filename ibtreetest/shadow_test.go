@@ -0,0 +1,34 @@
+package ibtreetest
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type rec struct{ k, v int }
+
+func recLess(a, b rec) bool { return a.k < b.k }
+func recEq(a, b rec) bool   { return a == b }
+
+func TestShadow(t *testing.T) {
+	src := rand.New(rand.NewSource(7))
+	s := NewShadow[rec](t, recLess, recEq)
+	versions := []*Shadow[rec]{s}
+	for i := 0; i < 500; i++ {
+		base := versions[src.Intn(len(versions))]
+		switch src.Intn(3) {
+		case 0:
+			base = base.Delete(rec{k: src.Intn(100)})
+		default:
+			base = base.Insert(rec{src.Intn(100), i}, rec{src.Intn(100), i})
+		}
+		base.Fetch(rec{k: src.Intn(100)})
+		versions = append(versions, base)
+	}
+	for _, v := range versions {
+		v.Check()
+	}
+	if versions[0].Len() != 0 || versions[0].Tree().Len() != 0 {
+		t.Fatalf("The first version should still be empty")
+	}
+}
@@ -0,0 +1,238 @@
+package ibtreetest
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/VictorLowther/ibtree"
+)
+
+// Distribution picks how a Workload chooses the keys its operations act on.
+type Distribution int
+
+const (
+	// Sequential hands out keys in ascending order, wrapping around at the end of the key space.
+	Sequential Distribution = iota
+	// Uniform picks every key in the key space with equal probability.
+	Uniform
+	// Zipfian picks small keys far more often than large ones, which models hot keys.
+	Zipfian
+	// Clustered picks keys from a normal distribution around a few randomly placed centers.
+	Clustered
+)
+
+// Kind is the kind of operation a WorkloadOp performs.
+type Kind int
+
+const (
+	// Insert inserts Item into the Tree.
+	Insert Kind = iota
+	// Delete deletes Item from the Tree.
+	Delete
+	// Fetch looks Item up in the Tree.
+	Fetch
+	// Scan iterates over up to Workload.ScanLen items starting at Item.
+	Scan
+)
+
+// Mix holds the relative weights of each kind of operation in a Workload.
+// A Mix of all zeros is treated as all inserts.
+type Mix struct {
+	Insert, Delete, Fetch, Scan int
+}
+
+// WorkloadOp is a single generated operation.
+type WorkloadOp[T any] struct {
+	Kind Kind
+	Key  uint64
+	Item T
+}
+
+// Workload describes a stream of operations against a Tree.  Make turns a key from
+// the key space into an item, which lets a Workload generate items of any type.
+// Make must return items that sort in the same order as their keys do, and must always
+// make equal items out of equal keys.
+type Workload[T any] struct {
+	Dist Distribution
+	// Keys is the size of the key space.  Keys are picked from 0 to Keys-1, and any size up to
+	// math.MaxUint64 works.  If it is 0, 1<<20 is used.
+	Keys uint64
+	// Mix picks how often each kind of operation happens.
+	Mix Mix
+	// Skew is the Zipfian exponent, and must be greater than 1.  If it is not, 1.1 is used.
+	Skew float64
+	// Clusters is the number of centers Clustered keys are picked around.  If it is 0, 16 is used.
+	Clusters int
+	// Spread is the standard deviation of Clustered keys around their center, as a fraction of
+	// the key space.  If it is 0, 0.001 is used.
+	Spread float64
+	// ScanLen is the most items a Scan will visit.  If it is 0, 100 is used.
+	ScanLen int
+	// Batch is the number of consecutive inserts or deletes that are applied to a Tree at once
+	// by Run.  Each batch creates a new version of the Tree, so smaller batches exercise the
+	// copy-on-write path harder.  If it is 0, 1 is used.
+	Batch int
+	// Seed seeds the random number generator, so the same Workload always produces the same operations.
+	Seed int64
+	// Make turns a key into an item.  It must be set.
+	Make func(key uint64) T
+}
+
+// Generator produces the operations described by a Workload.
+type Generator[T any] struct {
+	w       Workload[T]
+	src     *rand.Rand
+	zipf    *rand.Zipf
+	centers []uint64
+	seq     uint64
+	total   int
+}
+
+// Generator returns a new Generator for w.  Generators made from the same
+// Workload produce the same operations.
+func (w Workload[T]) Generator() *Generator[T] {
+	if w.Keys == 0 {
+		w.Keys = 1 << 20
+	}
+	if w.Skew <= 1 {
+		w.Skew = 1.1
+	}
+	if w.Clusters <= 0 {
+		w.Clusters = 16
+	}
+	if w.Spread <= 0 {
+		w.Spread = 0.001
+	}
+	if w.ScanLen <= 0 {
+		w.ScanLen = 100
+	}
+	if w.Batch <= 0 {
+		w.Batch = 1
+	}
+	if w.Mix == (Mix{}) {
+		w.Mix.Insert = 1
+	}
+	g := &Generator[T]{w: w, src: rand.New(rand.NewSource(w.Seed))}
+	g.total = w.Mix.Insert + w.Mix.Delete + w.Mix.Fetch + w.Mix.Scan
+	switch w.Dist {
+	case Zipfian:
+		g.zipf = rand.NewZipf(g.src, w.Skew, 1, w.Keys-1)
+	case Clustered:
+		g.centers = make([]uint64, w.Clusters)
+		for i := range g.centers {
+			g.centers[i] = g.uniform()
+		}
+	}
+	return g
+}
+
+// Key returns the next key from the Workload's distribution.
+func (g *Generator[T]) Key() uint64 {
+	switch g.w.Dist {
+	case Sequential:
+		res := g.seq
+		g.seq = (g.seq + 1) % g.w.Keys
+		return res
+	case Zipfian:
+		return g.zipf.Uint64()
+	case Clustered:
+		center := float64(g.centers[g.src.Intn(len(g.centers))])
+		k := math.Round(center + g.src.NormFloat64()*g.w.Spread*float64(g.w.Keys))
+		return uint64(math.Min(math.Max(k, 0), float64(g.w.Keys-1)))
+	default:
+		return g.uniform()
+	}
+}
+
+// uniform returns a key picked uniformly from the whole key space, which may hold
+// more than math.MaxInt64 keys.
+func (g *Generator[T]) uniform() uint64 {
+	if g.w.Keys <= math.MaxInt64 {
+		return uint64(g.src.Int63n(int64(g.w.Keys)))
+	}
+	// At least half of all uint64s are valid keys, so this rarely takes more than a couple of tries.
+	for {
+		if k := g.src.Uint64(); k < g.w.Keys {
+			return k
+		}
+	}
+}
+
+// Next returns the next operation.
+func (g *Generator[T]) Next() WorkloadOp[T] {
+	var res WorkloadOp[T]
+	switch pick := g.src.Intn(g.total); {
+	case pick < g.w.Mix.Insert:
+		res.Kind = Insert
+	case pick < g.w.Mix.Insert+g.w.Mix.Delete:
+		res.Kind = Delete
+	case pick < g.w.Mix.Insert+g.w.Mix.Delete+g.w.Mix.Fetch:
+		res.Kind = Fetch
+	default:
+		res.Kind = Scan
+	}
+	res.Key = g.Key()
+	res.Item = g.w.Make(res.Key)
+	return res
+}
+
+// Ops returns the next n operations.
+func (g *Generator[T]) Ops(n int) []WorkloadOp[T] {
+	res := make([]WorkloadOp[T], n)
+	for i := range res {
+		res[i] = g.Next()
+	}
+	return res
+}
+
+// Items returns n items made from keys drawn from the Workload's distribution.
+// It is handy for filling a Tree before running a Workload against it.
+func (g *Generator[T]) Items(n int) []T {
+	res := make([]T, n)
+	for i := range res {
+		res[i] = g.w.Make(g.Key())
+	}
+	return res
+}
+
+// Run applies ops to tree and returns the final version of it.  Runs of consecutive
+// inserts or deletes are applied Batch at a time, and every batch makes a new
+// version of the Tree that shares nodes with the previous one.
+func (g *Generator[T]) Run(tree *ibtree.Tree[T], ops []WorkloadOp[T]) *ibtree.Tree[T] {
+	batch := make([]T, 0, g.w.Batch)
+	kind := Insert
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		switch kind {
+		case Insert:
+			tree = tree.Insert(batch...)
+		case Delete:
+			tree, _ = tree.DeleteItems(batch...)
+		}
+		batch = batch[:0]
+	}
+	for _, op := range ops {
+		switch op.Kind {
+		case Insert, Delete:
+			if op.Kind != kind || len(batch) == g.w.Batch {
+				flush()
+				kind = op.Kind
+			}
+			batch = append(batch, op.Item)
+		case Fetch:
+			flush()
+			tree.Fetch(op.Item)
+		case Scan:
+			flush()
+			n := g.w.ScanLen
+			tree.After(ibtree.Lt(tree.Cmp(op.Item)), func(T) bool {
+				n--
+				return n > 0
+			})
+		}
+	}
+	flush()
+	return tree
+}
@@ -0,0 +1,116 @@
+package ibtreetest
+
+import (
+	"math"
+	"testing"
+
+	"github.com/VictorLowther/ibtree"
+)
+
+func TestWorkloadDistributions(t *testing.T) {
+	for _, dist := range []Distribution{Sequential, Uniform, Zipfian, Clustered} {
+		w := Workload[rec]{
+			Dist: dist,
+			Keys: 1000,
+			Seed: 3,
+			Make: func(k uint64) rec { return rec{k: int(k)} },
+		}
+		a, b := w.Generator(), w.Generator()
+		seen := map[uint64]int{}
+		for i := 0; i < 5000; i++ {
+			k := a.Key()
+			if k >= 1000 {
+				t.Fatalf("Distribution %d: key %d out of range", dist, k)
+			}
+			if k2 := b.Key(); k != k2 {
+				t.Fatalf("Distribution %d: generators with the same seed diverged", dist)
+			}
+			seen[k]++
+		}
+		switch dist {
+		case Sequential:
+			if len(seen) != 1000 || seen[0] != 5 {
+				t.Fatalf("Sequential keys did not cycle through the key space")
+			}
+		case Zipfian:
+			if seen[0] < seen[500]*10 {
+				t.Fatalf("Zipfian keys are not skewed: %d vs %d", seen[0], seen[500])
+			}
+		}
+	}
+}
+
+func TestWorkloadRun(t *testing.T) {
+	w := Workload[rec]{
+		Dist:  Uniform,
+		Keys:  200,
+		Mix:   Mix{Insert: 4, Delete: 2, Fetch: 3, Scan: 1},
+		Batch: 5,
+		Seed:  11,
+		Make:  func(k uint64) rec { return rec{k: int(k)} },
+	}
+	g := w.Generator()
+	ops := g.Ops(2000)
+	kinds := map[Kind]int{}
+	expect := map[int]bool{}
+	for _, item := range g.Items(50) {
+		expect[item.k] = true
+	}
+	tree := ibtree.New[rec](recLess)
+	for k := range expect {
+		tree = tree.Insert(rec{k: k})
+	}
+	for _, op := range ops {
+		kinds[op.Kind]++
+		switch op.Kind {
+		case Insert:
+			expect[op.Item.k] = true
+		case Delete:
+			delete(expect, op.Item.k)
+		}
+	}
+	for _, k := range []Kind{Insert, Delete, Fetch, Scan} {
+		if kinds[k] == 0 {
+			t.Fatalf("Kind %d never generated", k)
+		}
+	}
+	start := tree.Len()
+	res := g.Run(tree, ops)
+	if tree.Len() != start {
+		t.Fatalf("Run modified the Tree it was passed")
+	}
+	if res.Len() != len(expect) {
+		t.Fatalf("Expected %d items, got %d", len(expect), res.Len())
+	}
+	res.Walk(func(r rec) bool {
+		if !expect[r.k] {
+			t.Fatalf("Unexpected item %v", r)
+		}
+		return true
+	})
+}
+
+func TestWorkloadHugeKeySpace(t *testing.T) {
+	for _, keys := range []uint64{math.MaxInt64, math.MaxInt64 + 1, math.MaxUint64} {
+		for _, dist := range []Distribution{Sequential, Uniform, Zipfian, Clustered} {
+			w := Workload[uint64]{
+				Dist: dist,
+				Keys: keys,
+				Seed: 5,
+				Make: func(k uint64) uint64 { return k },
+			}
+			g := w.Generator()
+			high := false
+			for i := 0; i < 1000; i++ {
+				k := g.Key()
+				if k >= keys {
+					t.Fatalf("Keys %d: key %d out of range", keys, k)
+				}
+				high = high || k > math.MaxInt64/2
+			}
+			if dist == Uniform && !high {
+				t.Fatalf("Keys %d: uniform keys never reached the top half of the key space", keys)
+			}
+		}
+	}
+}
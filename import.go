@@ -0,0 +1,82 @@
+package ibtree
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// Import builds a new Tree out of the items returned by next, which should return
+// io.EOF once it has no more items.  If next returns any other error, Import stops and
+// returns that error.  Items are added to the Tree as they are read.  As long as they arrive
+// in sorted order the Tree is built directly from them without any rebalancing,
+// which is much faster than inserting them one at a time.
+func Import[T any](lt LessThan[T], next func() (T, error)) (*Tree[T], error) {
+	return newLoader(New[T](lt)).load(next)
+}
+
+// load adds the items returned by next to l until next returns io.EOF, and then
+// returns the finished Tree.  If next returns any other error, load returns that error.
+func (l *loader[T]) load(next func() (T, error)) (*Tree[T], error) {
+	for {
+		item, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			l.finish()
+			return nil, err
+		}
+		l.add(item)
+	}
+	return l.finish(), nil
+}
+
+// ImportNDJSON builds a new Tree out of the newline-delimited records read from r,
+// such as those written by ExportNDJSON.  dec is called once per non-empty line to decode
+// it into an item.  See Import for details.
+func ImportNDJSON[T any](lt LessThan[T], r io.Reader, dec func([]byte) (T, error)) (*Tree[T], error) {
+	return Import(lt, ndjsonReader(r, dec))
+}
+
+// ndjsonReader returns a function suitable for Import that decodes one line from r at a time.
+func ndjsonReader[T any](r io.Reader, dec func([]byte) (T, error)) func() (T, error) {
+	in := bufio.NewReader(r)
+	return func() (item T, err error) {
+		for {
+			var line []byte
+			line, err = in.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				return dec(line)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// MergeSnapshot returns a new Tree with all the items from the newline-delimited records in r
+// added to it, such as those written by WriteRange or ExportNDJSON.  dec is called once per non-empty
+// line to decode it into an item.  Items are merged in the same way Insert would merge them, so
+// they replace equal items already in the Tree unless the Tree was created with StableTies.
+//
+// The records are loaded into a scratch Tree the same way Import loads them, which is built
+// directly from the records as long as they arrive in sorted order, and the scratch Tree is then
+// merged into t the same way Union does it, a whole subtree at a time.  Records that arrive out
+// of order are still merged correctly, just more slowly.  The new Tree shares nodes with t
+// where possible.  If reading or decoding fails, MergeSnapshot returns the error and t is
+// left as it was.
+func (t *Tree[T]) MergeSnapshot(r io.Reader, dec func([]byte) (T, error)) (*Tree[T], error) {
+	batch, err := newLoader(t.derive(nil, 0, t.nextGen())).load(ndjsonReader(r, dec))
+	if err != nil {
+		return nil, err
+	}
+	res := t.Union(batch)
+	res.deleted = t.deleted
+	ins := res.getNsp()
+	defer res.putNsp(ins)
+	res.compactIfNeeded(ins)
+	return res, nil
+}
@@ -0,0 +1,138 @@
+package ibtree
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func decodeKv(buf []byte) (res kv, err error) {
+	err = json.Unmarshal(buf, &res)
+	return
+}
+
+func TestImportNDJSON(t *testing.T) {
+	src := New[kv](kvl, kv{"b", 2}, kv{"a", 1}, kv{"c", 3}, kv{"d", 4})
+	buf := &strings.Builder{}
+	if err := src.ExportNDJSON(buf, func(v kv) ([]byte, error) { return json.Marshal(v) }); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	tree, err := ImportNDJSON[kv](kvl, strings.NewReader(buf.String()+"\n"), decodeKv)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	tree.root.balanced(t)
+	if tree.Len() != 4 {
+		t.Fatalf("Expected 4 items, got %d", tree.Len())
+	}
+	if v, _ := tree.Fetch(kv{Key: "c"}); v.Val != 3 {
+		t.Fatalf("Expected c to be 3, got %d", v.Val)
+	}
+	in := `{"key":"z","val":1}
+{"key":"a","val":1}
+{"key":"a","val":2}`
+	if tree, err = ImportNDJSON[kv](kvl, strings.NewReader(in), decodeKv); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if v, _ := tree.Fetch(kv{Key: "a"}); tree.Len() != 2 || v.Val != 2 {
+		t.Fatalf("Out of order import failed")
+	}
+	if _, err = ImportNDJSON[kv](kvl, strings.NewReader("{\n"), decodeKv); err == nil {
+		t.Fatalf("Expected a decode error")
+	}
+}
+
+func TestImport(t *testing.T) {
+	for _, items := range [][]int{{}, {1, 1, 2, 3, 3, 3}, rand.Perm(1000)} {
+		i := 0
+		tree, err := Import[int](il, func() (int, error) {
+			if i == len(items) {
+				return 0, io.EOF
+			}
+			i++
+			return items[i-1], nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		tree.root.balanced(t)
+		expect := New[int](il, items...)
+		if tree.Len() != expect.Len() {
+			t.Fatalf("Expected %d items, got %d", expect.Len(), tree.Len())
+		}
+		tree = tree.Insert(-1)
+		tree.root.balanced(t)
+	}
+	boom := errors.New("boom")
+	if _, err := Import[int](il, func() (int, error) { return 0, boom }); err != boom {
+		t.Fatalf("Expected boom, got %v", err)
+	}
+}
+
+func TestMergeSnapshot(t *testing.T) {
+	tree := New[kv](kvl, kv{"t1/a", 1}, kv{"t2/a", 2}, kv{"t3/a", 3})
+	src := New[kv](kvl, kv{"t2/a", 20}, kv{"t2/b", 21}, kv{"t4/a", 4})
+	buf := &strings.Builder{}
+	if err := src.WriteRange(buf, Lt(src.Cmp(kv{Key: "t2/"})), Gte(src.Cmp(kv{Key: "t3/"})),
+		func(v kv) ([]byte, error) { return json.Marshal(v) }); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	res, err := tree.MergeSnapshot(strings.NewReader(buf.String()), decodeKv)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	res.root.balanced(t)
+	if res.Len() != 4 || tree.Len() != 3 {
+		t.Fatalf("Expected 4 and 3 items, got %d and %d", res.Len(), tree.Len())
+	}
+	if v, _ := res.Fetch(kv{Key: "t2/a"}); v.Val != 20 {
+		t.Fatalf("Expected t2/a to be replaced, got %v", v)
+	}
+	if v, _ := tree.Fetch(kv{Key: "t2/a"}); v.Val != 2 {
+		t.Fatalf("Original tree was modified")
+	}
+	if _, err = tree.MergeSnapshot(strings.NewReader("{\"key\":\n"), decodeKv); err == nil {
+		t.Fatalf("Expected a decode error")
+	}
+}
+
+func TestMergeSnapshotUnsorted(t *testing.T) {
+	tree := New[kv](kvl, kv{"b", 1}, kv{"d", 1}, kv{"f", 1})
+	in := `{"key":"e","val":2}
+{"key":"a","val":2}
+{"key":"d","val":2}
+{"key":"a","val":3}
+{"key":"c","val":2}`
+	res, err := tree.MergeSnapshot(strings.NewReader(in), decodeKv)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	res.root.balanced(t)
+	want := []kv{{"a", 3}, {"b", 1}, {"c", 2}, {"d", 2}, {"e", 2}, {"f", 1}}
+	if got := res.Items(); len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("Expected %v, got %v", want, got)
+			}
+		}
+	}
+	if v, _ := tree.Fetch(kv{Key: "d"}); v.Val != 1 || tree.Len() != 3 {
+		t.Fatalf("Original tree was modified")
+	}
+	stable := NewWith[kv](kvl, StableTies()).Insert(kv{"a", 1}, kv{"b", 1})
+	if res, err = stable.MergeSnapshot(strings.NewReader(in), decodeKv); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	res.root.balanced(t)
+	if all := res.GetAll(res.Cmp(kv{Key: "a"})); len(all) != 3 || all[0].Val != 1 || all[1].Val != 2 || all[2].Val != 3 {
+		t.Fatalf("Expected the equal items in insertion order, got %v", all)
+	}
+	if res.Len() != 7 {
+		t.Fatalf("Expected 7 items, got %d", res.Len())
+	}
+}
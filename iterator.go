@@ -1,5 +1,7 @@
 package ibtree
 
+import "context"
+
 // Test is a function signature that is used for iterating through
 // a Tree along with the signature that Range, Before, and After
 // discriminators must match.
@@ -44,6 +46,12 @@ func Ne[T any](c CompareAgainst[T]) Test[T] {
 	return func(idx T) bool { return c(idx) != Equal }
 }
 
+// pathStack returns an empty stack with enough room to hold the
+// longest path from the root of t to a leaf without growing.
+func (t *Tree[T]) pathStack() []*node[T] {
+	return make([]*node[T], 0, height(t.root))
+}
+
 // cmpIter holds state needed to iterate over a binary Tree.
 // You must not modify the Tree while iterating over it, lest you
 // get undefined results and/or panics.
@@ -53,6 +61,9 @@ type cmpIter[T any] struct {
 	workingNode *node[T]
 	start, stop Test[T]
 	ascending   bool
+	last        T    // The last item Next or Prev returned.
+	anchored    bool // Whether last holds anything.
+	rebased     bool // Whether the next move must start from last in a new Tree.
 }
 
 func (i *cmpIter[T]) clearStack() {
@@ -79,19 +90,161 @@ type Iter[T any] interface {
 	// assuming the previous call to Next or Prev returned true.  It will panic
 	// otherwise.
 	Item() T
+	// Rebase moves the Iterator over to t, which should be a newer version of the Tree
+	// it was created from.  The next call to Next will return the first item in t after
+	// the last item the Iterator returned, and the next call to Prev will return the
+	// last item in t before it, whether or not that item is still in t.  Rebase works
+	// even after Next or Prev have run off the end of the Tree, which makes it suitable
+	// for following a Tree that is being appended to.  It will return false if the
+	// Iterator cannot be rebased, or if it has been released.
+	Rebase(t *Tree[T]) bool
+	// Seek moves the Iterator to the first item it would return that is greater than or
+	// equal to the reference cmp wraps, whether that is ahead of or behind the current item.
+	// It returns true if there is such an item, and Item will then return it.  Otherwise it
+	// returns false, just as Next does once it runs off the end.  Seek will also return false
+	// if the Iterator cannot seek, or if it has been released or run off the end.
+	Seek(cmp CompareAgainst[T]) bool
+	// Clone returns a new Iterator at the same position as this one.  The two can be moved
+	// independently of each other, which allows looking ahead and then coming back.
+	Clone() Iter[T]
+	// Peek returns the item the next call to Next would move to and true, without moving
+	// the Iterator.  If Next would return false, Peek returns a zero T and false.
+	Peek() (T, bool)
+	// Reset turns the Iterator into a fresh one over t bounded by start and stop, as if it
+	// had just been returned by t.Iterator(start, stop), reusing the space it has already
+	// allocated.  It works on released Iterators as well.  It will return false if the
+	// Iterator cannot be reset.
+	Reset(t *Tree[T], start, stop Test[T]) bool
+}
+
+// following returns the node that comes after the one on top of stack, which must hold
+// the current node on top of every node whose left subtree iteration is still inside.
+// If desc is set, it returns the node that comes before it instead, and stack must hold
+// the nodes whose right subtrees iteration is still inside.
+func following[T any](stack []*node[T], desc bool) *node[T] {
+	if n := stack[len(stack)-1].child(desc); n != nil {
+		for n.child(!desc) != nil {
+			n = n.child(!desc)
+		}
+		return n
+	}
+	if len(stack) > 1 {
+		return stack[len(stack)-2]
+	}
+	return nil
+}
+
+// peekClone implements Peek by moving a clone of iter.
+func peekClone[T any](iter Iter[T]) (item T, found bool) {
+	c := iter.Clone()
+	defer c.Release()
+	if c.Next() {
+		return c.Item(), true
+	}
+	return
 }
 
 // Release releases the state the cmpIter holds.
 // Subsequent calls to Next will return false, and subsequent
 // calls to Item will panic.
 func (i *cmpIter[T]) Release() {
-	i.clearStack()
-	i.workingNode = nil
+	i.finish()
 	i.start = nil
 	i.stop = nil
+	var ref T
+	i.last, i.anchored, i.rebased = ref, false, false
+}
+
+// finish is called when iteration runs off the end of the Tree.  Unlike Release,
+// it keeps enough state around for Rebase to pick up where iteration left off.
+func (i *cmpIter[T]) finish() {
+	i.clearStack()
+	i.workingNode = nil
 	i.t = nil
 }
 
+// Rebase moves the cmpIter over to t.
+func (i *cmpIter[T]) Rebase(t *Tree[T]) bool {
+	if t == nil || (i.t == nil && !i.anchored) {
+		return false
+	}
+	i.clearStack()
+	i.t = t
+	i.workingNode = t.root
+	i.rebased = i.anchored
+	return true
+}
+
+// Seek moves the cmpIter to the first item in bounds that is not less than cmp.
+func (i *cmpIter[T]) Seek(cmp CompareAgainst[T]) bool {
+	if i.t == nil {
+		return false
+	}
+	i.t.checkRead()
+	i.clearStack()
+	i.workingNode = i.t.root
+	i.rebased = false
+	old := i.start
+	i.start = either(old, Lt(cmp))
+	defer func() { i.start = old }()
+	return i.init(true, i.stop)
+}
+
+// Clone returns a copy of the cmpIter with its own stack.
+func (i *cmpIter[T]) Clone() Iter[T] {
+	res := *i
+	res.stack = append(make([]*node[T], 0, cap(i.stack)), i.stack...)
+	return &res
+}
+
+// Reset reuses the cmpIter and its stack for a new iteration over t.
+func (i *cmpIter[T]) Reset(t *Tree[T], start, stop Test[T]) bool {
+	if t == nil {
+		return false
+	}
+	i.Release()
+	if need := height(t.root); cap(i.stack) < need {
+		i.stack = make([]*node[T], 0, need)
+	}
+	i.t, i.workingNode = t, t.root
+	i.start, i.stop = start, stop
+	i.ascending = false
+	return true
+}
+
+// Peek returns the item after the current one without moving the cmpIter.
+func (i *cmpIter[T]) Peek() (item T, found bool) {
+	if len(i.stack) == 0 || !i.ascending {
+		return peekClone[T](i)
+	}
+	i.t.checkRead()
+	if n := following(i.stack, false); n != nil && (i.stop == nil || !i.stop(n.i)) {
+		return n.i, true
+	}
+	return
+}
+
+// resume starts iteration in a new Tree from the last item returned.
+func (i *cmpIter[T]) resume(ascending bool) bool {
+	i.rebased = false
+	if ascending {
+		old := i.start
+		i.start = Lte(i.t.Cmp(i.last))
+		defer func() { i.start = old }()
+		return i.init(true, i.stop)
+	}
+	old := i.stop
+	i.stop = Gte(i.t.Cmp(i.last))
+	defer func() { i.stop = old }()
+	return i.init(false, i.start)
+}
+
+// moved records the item the cmpIter just moved to.
+func (i *cmpIter[T]) moved() bool {
+	i.last, i.anchored = i.workingNode.i, true
+	return true
+}
+
 func (i *cmpIter[T]) stackHead() *node[T] {
 	switch idx := len(i.stack); idx {
 	case 0:
@@ -166,12 +319,12 @@ func (i *cmpIter[T]) init(ascending bool, orNot Test[T]) bool {
 		i.ascending = ascending
 		i.workingNode = i.stackHead()
 		if i.workingNode == nil || (orNot != nil && orNot(i.workingNode.i)) {
-			i.Release()
+			i.finish()
 			return false
 		}
-		return true
+		return i.moved()
 	} else {
-		i.Release()
+		i.finish()
 		return false
 	}
 }
@@ -185,19 +338,13 @@ func (i *cmpIter[T]) changeDirection() bool {
 	if i.ascending {
 		old = i.start
 		i.start = Lte(i.t.Cmp(v))
-		if !i.Next() {
-			return false
-		}
-		i.start = old
-	} else {
-		old = i.stop
-		i.stop = Gte(i.t.Cmp(v))
-		if !i.Prev() {
-			return false
-		}
-		i.stop = old
+		defer func() { i.start = old }()
+		return i.Next()
 	}
-	return true
+	old = i.stop
+	i.stop = Gte(i.t.Cmp(v))
+	defer func() { i.stop = old }()
+	return i.Prev()
 }
 
 // Next walks to the next larger node in the Tree and returns true,
@@ -206,7 +353,13 @@ func (i *cmpIter[T]) changeDirection() bool {
 // If Next returns true, Item will return the item that
 // the current node contains.
 func (i *cmpIter[T]) Next() bool {
+	if i.t != nil {
+		i.t.checkRead()
+	}
 	if len(i.stack) == 0 {
+		if i.rebased {
+			return i.resume(true)
+		}
 		return i.init(true, i.stop)
 	}
 	if !i.ascending && !i.changeDirection() {
@@ -223,10 +376,10 @@ func (i *cmpIter[T]) Next() bool {
 		}
 	}
 	if i.workingNode == nil || (i.stop != nil && i.stop(i.workingNode.i)) {
-		i.Release()
+		i.finish()
 		return false
 	}
-	return true
+	return i.moved()
 }
 
 // Prev walks to the next smaller node in the Tree and returns true,
@@ -235,7 +388,13 @@ func (i *cmpIter[T]) Next() bool {
 // If Prev returns true, Item will return the item that
 // the current node contains.
 func (i *cmpIter[T]) Prev() bool {
+	if i.t != nil {
+		i.t.checkRead()
+	}
 	if len(i.stack) == 0 {
+		if i.rebased {
+			return i.resume(false)
+		}
 		return i.init(false, i.stop)
 	}
 	if i.ascending && !i.changeDirection() {
@@ -252,10 +411,10 @@ func (i *cmpIter[T]) Prev() bool {
 		}
 	}
 	if i.workingNode == nil || (i.start != nil && i.start(i.workingNode.i)) {
-		i.Release()
+		i.finish()
 		return false
 	}
-	return true
+	return i.moved()
 }
 
 // Iterator creates a new cmpIter that will ignore all items on the left for which start returns true and
@@ -286,6 +445,7 @@ func (i *cmpIter[T]) Prev() bool {
 func (t *Tree[T]) Iterator(start, stop Test[T]) Iter[T] {
 	return &cmpIter[T]{
 		t:           t,
+		stack:       t.pathStack(),
 		workingNode: t.root,
 		start:       start,
 		stop:        stop,
@@ -347,10 +507,141 @@ func (t *Tree[T]) Walk(iterator Test[T]) {
 	}
 }
 
+// chunker gathers items into batches for WalkChunks.
+type chunker[T any] struct {
+	buf []T
+	fn  func([]T) bool
+}
+
+// walk adds the items in the subtree rooted at n to c.buf in order, handing c.buf
+// to c.fn whenever it fills up.  It returns false once c.fn does.
+func (c *chunker[T]) walk(n *node[T]) bool {
+	for n != nil {
+		if !c.walk(n.l) {
+			return false
+		}
+		c.buf = append(c.buf, n.i)
+		if len(c.buf) == cap(c.buf) {
+			if !c.fn(c.buf) {
+				return false
+			}
+			c.buf = c.buf[:0]
+		}
+		n = n.r
+	}
+	return true
+}
+
+// WalkChunks calls fn with the items in the Tree in ascending order, batched into
+// chunks of size items.  Only the last chunk can be shorter than that.  If size is less
+// than 1, every chunk holds a single item.  WalkChunks stops early if fn returns false.
+//
+// The nodes of the Tree are walked directly, and the same slice is reused for every chunk,
+// so fn must copy anything it wants to keep after it returns.
+func (t *Tree[T]) WalkChunks(size int, fn func(chunk []T) bool) {
+	t.checkRead()
+	if t.root == nil {
+		return
+	}
+	if size < 1 {
+		size = 1
+	} else if size > t.count {
+		size = t.count
+	}
+	c := &chunker[T]{buf: make([]T, 0, size), fn: fn}
+	if c.walk(t.root) && len(c.buf) > 0 {
+		fn(c.buf)
+	}
+}
+
+// WalkLevels calls fn once for each item in the Tree in level order: first the item at
+// the root of the Tree, which is at depth 0, then the items at depth 1 from left to right,
+// and so on down the Tree.  WalkLevels stops early if fn returns false.
+//
+// Inserting the items into an empty Tree in the order WalkLevels visits them rebuilds the
+// Tree with the same shape without any rebalancing, and the depths show how well balanced
+// the Tree is without looking at its nodes.
+func (t *Tree[T]) WalkLevels(fn func(item T, depth int) bool) {
+	t.checkRead()
+	if t.root == nil {
+		return
+	}
+	level, next := []*node[T]{t.root}, []*node[T]{}
+	for depth := 0; len(level) > 0; depth++ {
+		for _, n := range level {
+			if !fn(n.i, depth) {
+				return
+			}
+			if n.l != nil {
+				next = append(next, n.l)
+			}
+			if n.r != nil {
+				next = append(next, n.r)
+			}
+		}
+		level, next = next, level[:0]
+	}
+}
+
+// RangeCtx is Range that also stops as soon as ctx is cancelled, in which case it
+// returns ctx.Err().  It returns nil if iteration ran to completion or iterator returned false.
+func (t *Tree[T]) RangeCtx(ctx context.Context, start, stop, iterator Test[T]) error {
+	done := ctx.Done()
+	i := t.Iterator(start, stop)
+	defer i.Release()
+	for i.Next() {
+		select {
+		case <-done:
+			return ctx.Err()
+		default:
+		}
+		if !iterator(i.Item()) {
+			break
+		}
+	}
+	return nil
+}
+
+// WalkCtx is Walk that also stops as soon as ctx is cancelled.  See RangeCtx.
+func (t *Tree[T]) WalkCtx(ctx context.Context, iterator Test[T]) error {
+	return t.RangeCtx(ctx, nil, nil, iterator)
+}
+
+// Stream sends the items between start and stop to the returned channel in ascending order
+// from a new goroutine, and closes it once they have all been sent.  The channel is unbuffered,
+// so the goroutine only gets ahead of the reader by one item.  If ctx is cancelled, the
+// goroutine stops and closes the channel early.  Callers that stop reading before the channel
+// is closed must cancel ctx, or the goroutine will never exit.
+func (t *Tree[T]) Stream(ctx context.Context, start, stop Test[T]) <-chan T {
+	res := make(chan T)
+	go func() {
+		defer close(res)
+		done := ctx.Done()
+		t.Range(start, stop, func(item T) bool {
+			// Check for cancellation first, since select picks randomly between ready cases.
+			if ctx.Err() != nil {
+				return false
+			}
+			select {
+			case res <- item:
+				return true
+			case <-done:
+				return false
+			}
+		})
+	}()
+	return res
+}
+
+// rangeIter walks a window of positions in a Tree.  Its stack holds the whole path
+// from the root of the Tree down to the current node, so it can step either way.
 type rangeIter[T any] struct {
-	t             *Tree[T]
-	stack         []*node[T]
-	offset, limit int
+	t     *Tree[T]
+	stack []*node[T]
+	limit int  // How many more items the rangeIter can return, or -1 for no limit.
+	first int  // The position of the first item the rangeIter can return.
+	pos   int  // The position of the current item.
+	desc  bool // Whether the rangeIter starts from the largest item instead of the smallest.
 }
 
 func (r *rangeIter[T]) workingNode() *node[T] {
@@ -372,6 +663,29 @@ func (r *rangeIter[T]) pop() *node[T] {
 	return res
 }
 
+// side returns the side of a node the items after it are on, going forwards if ahead
+// is set and backwards otherwise.
+func (r *rangeIter[T]) side(ahead bool) bool {
+	return r.desc == ahead
+}
+
+// step moves the current node one item forwards if ahead is set, or one item backwards
+// otherwise.  Each item is pushed and popped once over a full walk, so a step takes O(1)
+// amortized time whichever way it goes.
+func (r *rangeIter[T]) step(ahead bool) {
+	side := r.side(ahead)
+	if n := r.workingNode().child(side); n != nil {
+		r.toEdge(n, !side)
+		return
+	}
+	for {
+		n := r.pop()
+		if p := r.workingNode(); p == nil || p.child(!side) == n {
+			return
+		}
+	}
+}
+
 func (r *rangeIter[T]) Release() {
 	r.stack = nil
 	r.t = nil
@@ -385,45 +699,167 @@ func (r *rangeIter[T]) Item() T {
 	return n.i
 }
 
+// Prev moves back to the previous item in the window of the rangeIter.  If iteration has
+// not started yet, it starts from the last item in the window.
 func (r *rangeIter[T]) Prev() bool {
+	if r.t == nil {
+		return false
+	}
+	r.t.checkRead()
+	if len(r.stack) > 0 {
+		if r.pos == r.first {
+			r.Release()
+			return false
+		}
+		r.step(false)
+		r.pos--
+		if r.limit >= 0 {
+			r.limit++
+		}
+		return true
+	}
+	end := r.end()
+	if target := end - 1; target >= r.first {
+		r.moveTo(target, end)
+		return true
+	}
+	r.Release()
 	return false
 }
 
-func (r *rangeIter[T]) min(n *node[T]) {
-	for {
+// Rebase is not supported by the Iter OffsetAndLimit returns.
+func (r *rangeIter[T]) Rebase(*Tree[T]) bool {
+	return false
+}
+
+// toEdge pushes the path from n down to the last node in its subtree on the given side.
+func (r *rangeIter[T]) toEdge(n *node[T], side bool) {
+	for ; n != nil; n = n.child(side) {
 		r.stack = append(r.stack, n)
-		if n.l == nil {
-			return
+	}
+}
+
+// Clone returns a copy of the rangeIter with its own stack.
+func (r *rangeIter[T]) Clone() Iter[T] {
+	res := *r
+	res.stack = append(make([]*node[T], 0, cap(r.stack)), r.stack...)
+	return &res
+}
+
+// Reset is not supported by the Iter OffsetAndLimit returns.
+func (r *rangeIter[T]) Reset(*Tree[T], Test[T], Test[T]) bool {
+	return false
+}
+
+// Peek returns the item after the current one without moving the rangeIter.
+func (r *rangeIter[T]) Peek() (item T, found bool) {
+	if len(r.stack) == 0 {
+		return peekClone[T](r)
+	}
+	r.t.checkRead()
+	if r.limit == 0 {
+		return
+	}
+	side := r.side(true)
+	if n := r.workingNode().child(side); n != nil {
+		for n.child(!side) != nil {
+			n = n.child(!side)
+		}
+		return n.i, true
+	}
+	for k := len(r.stack) - 1; k > 0; k-- {
+		if r.stack[k-1].child(!side) == r.stack[k] {
+			return r.stack[k-1].i, true
+		}
+	}
+	return
+}
+
+// end returns the position just past the last item the rangeIter can return.
+func (r *rangeIter[T]) end() int {
+	if r.limit < 0 {
+		return r.t.count
+	}
+	res := r.first + r.limit
+	if len(r.stack) > 0 {
+		res = r.pos + 1 + r.limit
+	}
+	if res > r.t.count {
+		res = r.t.count
+	}
+	return res
+}
+
+// moveTo rebuilds the stack so that the item at position target, which must be
+// inside the window of the rangeIter, is the current one.
+func (r *rangeIter[T]) moveTo(target, end int) {
+	for k := range r.stack {
+		r.stack[k] = nil
+	}
+	r.stack = r.stack[:0]
+	for n, idx := r.t.root, target; ; {
+		r.stack = append(r.stack, n)
+		lc := countNodes(n.child(!r.desc))
+		if idx == lc {
+			break
+		}
+		if idx > lc {
+			idx -= lc + 1
+			n = n.child(r.desc)
+		} else {
+			n = n.child(!r.desc)
 		}
-		n = n.l
+	}
+	r.pos = target
+	if r.limit >= 0 {
+		r.limit = end - target - 1
 	}
 }
 
-func (r *rangeIter[T]) next() {
-	if r.offset > 0 {
-		r.offset--
+// Seek moves the rangeIter to the first item in its window that is not less than cmp.
+// Descending rangeIters cannot seek.
+func (r *rangeIter[T]) Seek(cmp CompareAgainst[T]) bool {
+	if r.t == nil || r.desc {
+		return false
+	}
+	r.t.checkRead()
+	end := r.end()
+	target := countWhile(r.t.root, Lt(cmp))
+	if target < r.first {
+		target = r.first
 	}
-	n := r.pop()
-	if n != nil && n.r != nil {
-		r.min(n.r)
+	if target >= end {
+		r.Release()
+		return false
 	}
+	r.moveTo(target, end)
+	return true
 }
 
 func (r *rangeIter[T]) Next() bool {
+	if r.t != nil {
+		r.t.checkRead()
+	}
 	if len(r.stack) == 0 {
 		if r.t == nil {
 			return false
 		}
-		if r.t.root != nil {
-			r.min(r.t.root)
-		}
-		for r.offset > 0 && len(r.stack) > 0 {
-			r.next()
+		// Use the subtree sizes to go straight to the first item instead of walking past the skipped ones.
+		end := r.end()
+		if r.first >= end {
+			r.Release()
+			return false
 		}
-	} else {
-		r.next()
+		r.moveTo(r.first, end)
+		return true
+	}
+	if r.limit == 0 {
+		r.Release()
+		return false
 	}
-	if r.limit == 0 || r.workingNode() == nil {
+	r.step(true)
+	r.pos++
+	if r.workingNode() == nil {
 		r.Release()
 		return false
 	}
@@ -435,17 +871,103 @@ func (r *rangeIter[T]) Next() bool {
 
 // OffsetAndLimit returns an Iter that skips the first offset items
 // and returns up to limit items. Passing limit of -1 will cause
-// OffsetAndLimit to iterate to the last item in the tree.
+// OffsetAndLimit to iterate to the last item in the tree.  The skipped
+// items are not visited, so starting at any offset takes O(log n) time.
 //
-// The Iter returned by OffsetAndLimit cannot run backwards -- the
-// Prev() method will always return false and not affect the current
-// position of the Iter.
+// The Iter returned by OffsetAndLimit can also run backwards, but never outside
+// of the items it would return going forwards.  Calling Prev before Next starts
+// from the last of those items.
 func (t *Tree[T]) OffsetAndLimit(offset, limit int) Iter[T] {
-	return &rangeIter[T]{t: t, offset: offset, limit: limit}
+	first := offset
+	if first < 0 {
+		first = 0
+	}
+	return &rangeIter[T]{t: t, stack: t.pathStack(), limit: limit, first: first}
+}
+
+// BoundedOffsetAndLimit is OffsetAndLimit for the items between start and stop, which work
+// the same way they do for Iterator.  offset and limit count from the first item start returns
+// false for, and the Iter never returns an item that stop returns true for.  The bounds are
+// turned into positions up front using the subtree sizes every node keeps, so paginating within
+// a range of keys does not need to count skipped items by hand.
+func (t *Tree[T]) BoundedOffsetAndLimit(start, stop Test[T], offset, limit int) Iter[T] {
+	lo, hi := 0, t.count
+	if start != nil {
+		lo = countWhile(t.root, start)
+	}
+	if stop != nil {
+		hi = countWhile(t.root, func(item T) bool { return !stop(item) })
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	first := lo + offset
+	if n := hi - first; limit < 0 || limit > n {
+		limit = n
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	return t.OffsetAndLimit(first, limit)
+}
+
+// ReverseOffsetAndLimit is OffsetAndLimit counting from the largest item in the Tree
+// instead of the smallest.  It skips the largest offset items, and returns up to limit
+// items in descending order, which is handy for newest-first paging.
+//
+// The Iter returned by ReverseOffsetAndLimit can run backwards the same way the one
+// OffsetAndLimit returns can, but it cannot Seek.
+func (t *Tree[T]) ReverseOffsetAndLimit(offset, limit int) Iter[T] {
+	res := t.OffsetAndLimit(offset, limit).(*rangeIter[T])
+	res.desc = true
+	return res
 }
 
 // All returns an iterator that will walk over the entries in the tree.
 // It is shorthand for t.Iterator(nil,nil) or t.OffsetAndLimit(0,-1)
 func (t *Tree[T]) All() Iter[T] {
-	return &rangeIter[T]{t: t, offset: 0, limit: -1}
+	return &rangeIter[T]{t: t, stack: t.pathStack(), limit: -1}
+}
+
+// filterIter skips over the items of the Iter it wraps that keep returns false for.
+type filterIter[T any] struct {
+	Iter[T]
+	keep Test[T]
+}
+
+// skip moves i in the direction step moves it until it lands on an item that should be kept.
+func (i *filterIter[T]) skip(ok bool, step func() bool) bool {
+	for ok && !i.keep(i.Iter.Item()) {
+		ok = step()
+	}
+	return ok
+}
+
+func (i *filterIter[T]) Next() bool {
+	return i.skip(i.Iter.Next(), i.Iter.Next)
+}
+
+func (i *filterIter[T]) Prev() bool {
+	return i.skip(i.Iter.Prev(), i.Iter.Prev)
+}
+
+// Seek moves the filterIter to the first kept item that is not less than cmp.
+func (i *filterIter[T]) Seek(cmp CompareAgainst[T]) bool {
+	return i.skip(i.Iter.Seek(cmp), i.Iter.Next)
+}
+
+func (i *filterIter[T]) Clone() Iter[T] {
+	return &filterIter[T]{Iter: i.Iter.Clone(), keep: i.keep}
+}
+
+func (i *filterIter[T]) Peek() (T, bool) {
+	return peekClone[T](i)
+}
+
+// FilterIter wraps inner in an Iter that skips over every item keep returns false for.
+// Everything else, including Rebase and Reset, is passed through to inner, so the
+// returned Iter can do whatever inner can.  keep is called every time an item is
+// considered, so it should be cheap.
+func FilterIter[T any](inner Iter[T], keep Test[T]) Iter[T] {
+	return &filterIter[T]{Iter: inner, keep: keep}
 }
@@ -0,0 +1,339 @@
+package ibtree
+
+// join returns a subtree holding all the items in l, then k, then all the items in r.
+// Every item in l must sort before k, and every item in r must sort after it.
+// l and r are not modified, but the returned subtree will share nodes with them.
+func (ns *nodeStack[T]) join(l *node[T], k T, r *node[T]) *node[T] {
+	lh, rh := height(l), height(r)
+	switch {
+	case lh > rh+ns.skew:
+		return ns.joinRight(l, k, r, rh)
+	case rh > lh+ns.skew:
+		return ns.joinLeft(l, k, r, lh)
+	}
+	res := &node[T]{l: l, r: r, i: k, genH: ns.gen << hOffset}
+	res.setHeight()
+	return res
+}
+
+// joinRight handles join when l is the taller subtree by walking down its right
+// spine until it finds a subtree short enough to become a sibling of r.
+func (ns *nodeStack[T]) joinRight(l *node[T], k T, r *node[T], rh int) *node[T] {
+	l = ns.copy(l)
+	if height(l.r) <= rh+ns.skew {
+		l.r = &node[T]{l: l.r, r: r, i: k, genH: ns.gen << hOffset}
+		l.r.setHeight()
+	} else {
+		l.r = ns.joinRight(l.r, k, r, rh)
+	}
+	return ns.restore(l)
+}
+
+// joinLeft is the mirror image of joinRight.
+func (ns *nodeStack[T]) joinLeft(l *node[T], k T, r *node[T], lh int) *node[T] {
+	r = ns.copy(r)
+	if height(r.l) <= lh+ns.skew {
+		r.l = &node[T]{l: l, r: r.l, i: k, genH: ns.gen << hOffset}
+		r.l.setHeight()
+	} else {
+		r.l = ns.joinLeft(l, k, r.l, lh)
+	}
+	return ns.restore(r)
+}
+
+// splitLast removes the largest item from the subtree rooted at n, which must not be nil.
+func (ns *nodeStack[T]) splitLast(n *node[T]) (*node[T], T) {
+	if n.r == nil {
+		return n.l, n.i
+	}
+	rest, k := ns.splitLast(n.r)
+	return ns.join(n.l, n.i, rest), k
+}
+
+// join2 returns a subtree holding all the items in l followed by all the items in r.
+func (ns *nodeStack[T]) join2(l, r *node[T]) *node[T] {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	rest, k := ns.splitLast(l)
+	return ns.join(rest, k, r)
+}
+
+// splitBy splits the subtree rooted at n into one holding the items isLeft returns true
+// for, and one holding the rest.  isLeft must return true for all the items up to some point
+// in the Tree, and false for the rest.  n is not modified.  lc is the number of items in l,
+// which is added up on the way down from the subtrees that go to the left whole.
+func (ns *nodeStack[T]) splitBy(n *node[T], isLeft Test[T]) (l, r *node[T], lc int) {
+	if n == nil {
+		return
+	}
+	if isLeft(n.i) {
+		rl, rr, rlc := ns.splitBy(n.r, isLeft)
+		return ns.join(n.l, n.i, rl), rr, countNodes(n.l) + 1 + rlc
+	}
+	ll, lr, llc := ns.splitBy(n.l, isLeft)
+	return ll, ns.join(lr, n.i, n.r), llc
+}
+
+// splitPos splits the subtree rooted at n into one holding its first k items, and one
+// holding the rest.  n is not modified.
+func (ns *nodeStack[T]) splitPos(n *node[T], k int) (l, r *node[T]) {
+	if n == nil {
+		return
+	}
+	lc := countNodes(n.l)
+	if k <= lc {
+		ll, lr := ns.splitPos(n.l, k)
+		return ll, ns.join(lr, n.i, n.r)
+	}
+	rl, rr := ns.splitPos(n.r, k-lc-1)
+	return ns.join(n.l, n.i, rl), rr
+}
+
+// split splits the subtree rooted at n into the items less than the reference cmp wraps,
+// the node holding the item equal to it (if any), and the items greater than it.
+func (ns *nodeStack[T]) split(n *node[T], cmp CompareAgainst[T]) (l, eq, r *node[T]) {
+	if n == nil {
+		return
+	}
+	switch cmp(n.i) {
+	case Equal:
+		return n.l, n, n.r
+	case Less:
+		rl, eq, rr := ns.split(n.r, cmp)
+		return ns.join(n.l, n.i, rl), eq, rr
+	case Greater:
+		ll, eq, lr := ns.split(n.l, cmp)
+		return ll, eq, ns.join(lr, n.i, n.r)
+	default:
+		panic(unorderable)
+	}
+}
+
+// nextGen returns a generation newer than that of t and any of others.  Nodes created with it
+// can be safely shared between any of the Trees and a new Tree created with derive.
+func (t *Tree[T]) nextGen(others ...*Tree[T]) uint64 {
+	gen := t.gen
+	for _, o := range others {
+		if o.gen > gen {
+			gen = o.gen
+		}
+	}
+	if gen < maxGen {
+		gen++
+	}
+	return gen
+}
+
+// joiner returns a nodeStack for making new nodes with generation gen.
+func (t *Tree[T]) joiner(gen uint64) *nodeStack[T] {
+	ns := t.getNsp()
+	ns.gen = gen
+	return ns
+}
+
+// derive makes a new Tree with the same ordering and options as t out of root.
+func (t *Tree[T]) derive(root *node[T], count int, gen uint64) *Tree[T] {
+	if gen >= maxGen {
+		// See Fork for why this is needed.
+		gen = 0
+		root = copyNodes(root, false)
+	}
+	return &Tree[T]{less: t.less, nsp: newPool[T](t.opts, t.nsp), opts: t.opts, root: root, count: count, gen: gen}
+}
+
+// SplitN partitions the Tree into n new Trees covering consecutive ranges of items,
+// each holding as close to Len()/n items as possible.  The first Tree holds the smallest
+// items, and the last Tree holds the largest.  If the Tree holds fewer than n items,
+// some of the returned Trees will be empty.  The new Trees share nodes with t.
+// Every cut is found using the subtree sizes each node keeps, and takes O(log Len()) time,
+// so SplitN takes O(n log Len()) time in all.  If n is less than 1, SplitN panics.
+func (t *Tree[T]) SplitN(n int) []*Tree[T] {
+	if n < 1 {
+		panic("SplitN needs at least one partition")
+	}
+	res := make([]*Tree[T], n)
+	gen := t.nextGen()
+	ns := t.joiner(gen)
+	defer t.putNsp(ns)
+	rest := t.root
+	for i := range res {
+		size := t.count / n
+		if i < t.count%n {
+			size++
+		}
+		var l *node[T]
+		l, rest = ns.splitPos(rest, size)
+		res[i] = t.derive(l, size, gen)
+	}
+	return res
+}
+
+// SplitAt splits the Tree after its n-th item.  first holds the n smallest items,
+// and rest holds everything else.  If n is less than 1, first will be empty, and if n is
+// at least Len(), rest will be empty.  Both new Trees share nodes with t.  The split point
+// is found using the subtree sizes every node keeps, so SplitAt runs in O(log n) time.
+func (t *Tree[T]) SplitAt(n int) (first, rest *Tree[T]) {
+	if n < 0 {
+		n = 0
+	} else if n > t.count {
+		n = t.count
+	}
+	gen := t.nextGen()
+	ns := t.joiner(gen)
+	defer t.putNsp(ns)
+	l, r := ns.splitPos(t.root, n)
+	return t.derive(l, n, gen), t.derive(r, t.count-n, gen)
+}
+
+// Split splits the Tree into one Tree holding the items that are less than the
+// reference at wraps, and one holding the items that are greater than or equal to it.
+// Only the nodes along the path to the split point are copied, and both new Trees share
+// the rest of their nodes with t.  The items on each side are counted along the way, so
+// Split runs in O(log n) time.
+func (t *Tree[T]) Split(at CompareAgainst[T]) (less, rest *Tree[T]) {
+	gen := t.nextGen()
+	ns := t.joiner(gen)
+	defer t.putNsp(ns)
+	l, r, lc := ns.splitBy(t.root, func(item T) bool {
+		switch at(item) {
+		case Less:
+			return true
+		case Equal, Greater:
+			return false
+		default:
+			panic(unorderable)
+		}
+	})
+	return t.derive(l, lc, gen), t.derive(r, t.count-lc, gen)
+}
+
+// cutRange splits the subtree rooted at n into the items at the start that start returns true
+// for, the items at the end that stop returns true for, and the items in between.
+// A nil start or stop matches no items.
+func (ns *nodeStack[T]) cutRange(n *node[T], start, stop Test[T]) (l, mid, r *node[T]) {
+	mid = n
+	if start != nil {
+		l, mid, _ = ns.splitBy(mid, start)
+	}
+	if stop != nil {
+		mid, r, _ = ns.splitBy(mid, func(item T) bool { return !stop(item) })
+	}
+	return
+}
+
+// DeleteRange returns a new Tree without the items between start and stop, along with the
+// number of items removed.  start and stop work the same way they do for Iterator, and a nil
+// start or stop leaves that end of the range open.  Instead of deleting the items one at a time,
+// DeleteRange cuts the range out and joins what is left in O(log n) time, and the new Tree
+// shares nodes with t.
+func (t *Tree[T]) DeleteRange(start, stop Test[T]) (into *Tree[T], deleted int) {
+	gen := t.nextGen()
+	ns := t.joiner(gen)
+	defer t.putNsp(ns)
+	l, mid, r := ns.cutRange(t.root, start, stop)
+	deleted = countNodes(mid)
+	if deleted == 0 {
+		return t, 0
+	}
+	return t.derive(ns.join2(l, r), t.count-deleted, gen), deleted
+}
+
+// filter returns a subtree holding the items in n that drop returns false for, along with
+// the number of items in it.  Subtrees that lose no items are kept as they are.
+func (ns *nodeStack[T]) filter(n *node[T], drop Test[T]) (res *node[T], kept int) {
+	if n == nil {
+		return nil, 0
+	}
+	l, lk := ns.filter(n.l, drop)
+	r, rk := ns.filter(n.r, drop)
+	switch {
+	case drop(n.i):
+		return ns.join2(l, r), lk + rk
+	case l == n.l && r == n.r:
+		return n, lk + rk + 1
+	default:
+		return ns.join(l, n.i, r), lk + rk + 1
+	}
+}
+
+// DeleteMatching returns a new Tree without the items that match returns true for, along
+// with the number of items removed.  Every item is tested once, and the new Tree is built in a
+// single pass that keeps every subtree with no matching items as it is, instead of making a
+// new Tree for every deleted item.  If nothing matches, t itself is returned.
+func (t *Tree[T]) DeleteMatching(match Test[T]) (into *Tree[T], deleted int) {
+	gen := t.nextGen()
+	ns := t.joiner(gen)
+	defer t.putNsp(ns)
+	root, count := ns.filter(t.root, match)
+	if root == t.root {
+		return t, 0
+	}
+	return t.derive(root, count, gen), t.count - count
+}
+
+// Retain returns a new Tree holding only the items in t that keep returns true for.
+// It is the inverse of DeleteMatching, and shares every subtree that loses no items with t.
+func (t *Tree[T]) Retain(keep Test[T]) *Tree[T] {
+	res, _ := t.DeleteMatching(func(item T) bool { return !keep(item) })
+	return res
+}
+
+// GetAll returns every item in the Tree that is equal to the reference cmp wraps, in
+// ascending order.  For Trees created with StableTies, equal items are returned in the order
+// they were inserted in.
+func (t *Tree[T]) GetAll(cmp CompareAgainst[T]) []T {
+	res := make([]T, 0, t.CountRange(Lt(cmp), Gt(cmp)))
+	t.Range(Lt(cmp), Gt(cmp), func(item T) bool {
+		res = append(res, item)
+		return true
+	})
+	return res
+}
+
+// DeleteAll returns a new Tree without any of the items that are equal to the reference cmp
+// wraps, along with the number of items removed.  See DeleteRange for how it works.
+func (t *Tree[T]) DeleteAll(cmp CompareAgainst[T]) (into *Tree[T], deleted int) {
+	return t.DeleteRange(Lt(cmp), Gt(cmp))
+}
+
+// Extract returns a new Tree holding only the items between start and stop, which work
+// the same way they do for Iterator.  A nil start or stop leaves that end of the range open.
+// The range is cut out of t in O(log n) time, and the new Tree shares nodes with t.
+func (t *Tree[T]) Extract(start, stop Test[T]) *Tree[T] {
+	gen := t.nextGen()
+	ns := t.joiner(gen)
+	defer t.putNsp(ns)
+	_, mid, _ := ns.cutRange(t.root, start, stop)
+	return t.derive(mid, countNodes(mid), gen)
+}
+
+// Concat returns a new Tree holding all the items in t followed by all the items in right,
+// which must be ordered the same way as t.  Every item in t must be less than every item
+// in right, or Concat panics.  If t was created with StableTies, items in t may also be
+// equal to items in right, and will stay ahead of them.  The Trees are joined in O(log n)
+// time, and the new Tree shares nodes with both of them.  Together with Split, this
+// allows ranges of items to be cut out of and spliced into Trees cheaply.
+func (t *Tree[T]) Concat(right *Tree[T]) *Tree[T] {
+	if t.root != nil && right.root != nil {
+		last, first := max(t.root).i, min(right.root).i
+		if t.less(first, last) || (!t.opts.stable && !t.less(last, first)) {
+			panic("Concat: the Trees overlap")
+		}
+	}
+	gen := t.nextGen(right)
+	ns := t.joiner(gen)
+	defer t.putNsp(ns)
+	return t.derive(ns.join2(t.root, right.root), t.count+right.count, gen)
+}
+
+// countNodes returns the number of nodes in the subtree rooted at n.
+func countNodes[T any](n *node[T]) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
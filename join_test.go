@@ -0,0 +1,287 @@
+package ibtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func checkTree(t *testing.T, tree *Tree[int], expect []int) {
+	t.Helper()
+	tree.root.balanced(t)
+	if tree.Len() != len(expect) {
+		t.Fatalf("Expected %d items, got %d", len(expect), tree.Len())
+	}
+	i := 0
+	tree.Walk(func(v int) bool {
+		if v != expect[i] {
+			t.Fatalf("Item %d: expected %d, got %d", i, expect[i], v)
+		}
+		i++
+		return true
+	})
+}
+
+func TestSplitN(t *testing.T) {
+	src := rand.New(rand.NewSource(4))
+	items := src.Perm(1000)
+	tree := New[int](il, items...)
+	for _, n := range []int{1, 2, 3, 7, 999, 1000, 1500} {
+		parts := tree.SplitN(n)
+		if len(parts) != n {
+			t.Fatalf("Expected %d parts, got %d", n, len(parts))
+		}
+		at := 0
+		for i, part := range parts {
+			size := 1000 / n
+			if i < 1000%n {
+				size++
+			}
+			expect := make([]int, size)
+			for j := range expect {
+				expect[j] = at + j
+			}
+			checkTree(t, part, expect)
+			at += size
+			part = part.Insert(-1, 5000)
+			part.root.balanced(t)
+		}
+	}
+	for _, part := range tree.SplitN(4) {
+		if part.Len() != 250 {
+			t.Fatalf("Expected 250 items in each part")
+		}
+	}
+	all := make([]int, 1000)
+	for i := range all {
+		all[i] = i
+	}
+	checkTree(t, tree, all)
+	stable := NewWith[ovr](ol, StableTies()).Insert(ovr{1, 0}, ovr{1, 1}, ovr{1, 2}, ovr{2, 3})
+	total := 0
+	for _, part := range stable.SplitN(2) {
+		if part.Len() != countNodes(part.root) {
+			t.Fatalf("Part length is wrong")
+		}
+		total += part.Len()
+	}
+	if total != 4 {
+		t.Fatalf("Expected 4 items in total, got %d", total)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	for _, skew := range []int{1, 3} {
+		for ln := 0; ln < 70; ln += 3 {
+			for rn := 0; rn < 300; rn += 17 {
+				l := make([]int, ln)
+				for i := range l {
+					l[i] = i
+				}
+				r := make([]int, rn)
+				for i := range r {
+					r[i] = ln + 1 + i
+				}
+				lt, rt := New[int](il, l...), New[int](il, r...)
+				ns := lt.joiner(lt.nextGen(rt))
+				ns.skew = skew
+				res := ns.join(lt.root, ln, rt.root)
+				res.balancedWithin(t, skew)
+				if countNodes(res) != ln+rn+1 {
+					t.Fatalf("Join lost items")
+				}
+				res = ns.join2(lt.root, rt.root)
+				res.balancedWithin(t, skew)
+				lt.root.balanced(t)
+				rt.root.balanced(t)
+				lt.putNsp(ns)
+			}
+		}
+	}
+}
+
+func TestSplitAt(t *testing.T) {
+	items := rand.New(rand.NewSource(5)).Perm(500)
+	tree := New[int](il, items...)
+	all := make([]int, 500)
+	for i := range all {
+		all[i] = i
+	}
+	for _, n := range []int{-1, 0, 1, 17, 250, 499, 500, 600} {
+		first, rest := tree.SplitAt(n)
+		at := n
+		if at < 0 {
+			at = 0
+		} else if at > 500 {
+			at = 500
+		}
+		checkTree(t, first, all[:at])
+		checkTree(t, rest, all[at:])
+		first.Insert(1000).root.balanced(t)
+		rest.Insert(-1).root.balanced(t)
+	}
+	checkTree(t, tree, all)
+	stable := NewWith[ovr](ol, StableTies()).Insert(ovr{1, 0}, ovr{1, 1}, ovr{1, 2}, ovr{2, 3})
+	first, rest := stable.SplitAt(2)
+	if first.Len() != 2 || countNodes(first.root) != 2 || rest.Len() != 2 || countNodes(rest.root) != 2 {
+		t.Fatalf("SplitAt did not split equal items by position")
+	}
+	if v, _ := rest.Min(); v.mark != 2 {
+		t.Fatalf("Expected the third item to start rest, got %v", v)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tree := New[int](il, rand.New(rand.NewSource(6)).Perm(300)...)
+	all := make([]int, 300)
+	for i := range all {
+		all[i] = i
+	}
+	for _, at := range []int{-5, 0, 1, 150, 299, 300, 1000} {
+		less, rest := tree.Split(tree.Cmp(at))
+		pos := at
+		if pos < 0 {
+			pos = 0
+		} else if pos > 300 {
+			pos = 300
+		}
+		checkTree(t, less, all[:pos])
+		checkTree(t, rest, all[pos:])
+	}
+	stable := NewWith[ovr](ol, StableTies()).Insert(ovr{1, 0}, ovr{2, 1}, ovr{2, 2}, ovr{3, 3})
+	less, rest := stable.Split(stable.Cmp(ovr{i: 2}))
+	if less.Len() != 1 || rest.Len() != 3 || countNodes(rest.root) != 3 {
+		t.Fatalf("Expected every item equal to the split point to be in rest")
+	}
+	checkTree(t, tree, all)
+}
+
+func TestConcat(t *testing.T) {
+	all := make([]int, 1000)
+	for i := range all {
+		all[i] = i
+	}
+	tree := New[int](il, all...)
+	for _, at := range []int{0, 1, 10, 500, 999, 1000} {
+		less, rest := tree.SplitAt(at)
+		checkTree(t, less.Concat(rest), all)
+		checkTree(t, rest.Concat(New[int](il)), all[at:])
+	}
+	// Cut a range out and splice it back in.
+	head, rest := tree.SplitAt(100)
+	mid, tail := rest.SplitAt(50)
+	checkTree(t, head.Concat(tail), append(append([]int{}, all[:100]...), all[150:]...))
+	checkTree(t, head.Concat(mid).Concat(tail), all)
+	stable := NewWith[ovr](ol, StableTies())
+	joined := stable.Insert(ovr{1, 0}, ovr{2, 1}).Concat(stable.Insert(ovr{2, 2}))
+	if v, _ := joined.Max(); joined.Len() != 3 || v.mark != 2 {
+		t.Fatalf("Expected equal items from the right Tree to stay last")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Expected overlapping Trees to panic")
+		}
+	}()
+	tail.Concat(head)
+}
+
+func TestDeleteRange(t *testing.T) {
+	tree := New[int](il, rand.New(rand.NewSource(24)).Perm(500)...)
+	for _, tc := range []struct{ lo, hi int }{{-5, 10}, {0, 0}, {100, 400}, {250, 250}, {490, 600}, {-1, 600}, {300, 200}} {
+		var start, stop Test[int]
+		if tc.lo >= 0 {
+			start = Lt(tree.Cmp(tc.lo))
+		}
+		if tc.hi < 500 {
+			stop = Gt(tree.Cmp(tc.hi))
+		}
+		expect := []int{}
+		for i := 0; i < 500; i++ {
+			if (start != nil && i < tc.lo) || (stop != nil && i > tc.hi) {
+				expect = append(expect, i)
+			}
+		}
+		res, deleted := tree.DeleteRange(start, stop)
+		if deleted != 500-len(expect) {
+			t.Errorf("DeleteRange(%d, %d): expected %d deleted, got %d", tc.lo, tc.hi, 500-len(expect), deleted)
+		}
+		checkTree(t, res, expect)
+	}
+	if tree.Len() != 500 {
+		t.Fatalf("DeleteRange modified the original Tree")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tree := New[int](il, rand.New(rand.NewSource(26)).Perm(300)...)
+	res := tree.Extract(Lt(tree.Cmp(100)), Gte(tree.Cmp(200)))
+	expect := make([]int, 100)
+	for i := range expect {
+		expect[i] = i + 100
+	}
+	checkTree(t, res, expect)
+	checkTree(t, tree.Extract(Lt(tree.Cmp(200)), Gte(tree.Cmp(100))), []int{})
+	checkTree(t, tree.Extract(Lte(tree.Cmp(297)), nil), []int{298, 299})
+	if tree.Len() != 300 {
+		t.Fatalf("Extract modified the original Tree")
+	}
+	if res.Insert(1000).Len() != 101 || tree.HasItem(1000) {
+		t.Fatalf("Extracted Tree is not independent")
+	}
+}
+
+func TestDeleteMatching(t *testing.T) {
+	tree := New[int](il, rand.New(rand.NewSource(32)).Perm(1000)...)
+	res, deleted := tree.DeleteMatching(func(i int) bool { return i%3 == 0 })
+	expect := []int{}
+	for i := 0; i < 1000; i++ {
+		if i%3 != 0 {
+			expect = append(expect, i)
+		}
+	}
+	if deleted != 1000-len(expect) {
+		t.Fatalf("Expected %d deleted, got %d", 1000-len(expect), deleted)
+	}
+	checkTree(t, res, expect)
+	if same, deleted := res.DeleteMatching(func(i int) bool { return i < 0 }); same != res || deleted != 0 {
+		t.Fatalf("Deleting nothing should return the original Tree")
+	}
+	empty, _ := tree.DeleteMatching(func(int) bool { return true })
+	checkTree(t, empty, []int{})
+}
+
+func TestRetain(t *testing.T) {
+	tree := New[int](il, rand.New(rand.NewSource(33)).Perm(500)...)
+	res := tree.Retain(func(i int) bool { return i >= 100 && i < 150 })
+	expect := make([]int, 50)
+	for i := range expect {
+		expect[i] = i + 100
+	}
+	checkTree(t, res, expect)
+	if tree.Retain(func(int) bool { return true }) != tree {
+		t.Fatalf("Retaining everything should return the original Tree")
+	}
+}
+
+func TestGetAllDeleteAll(t *testing.T) {
+	tree := NewWith[ovr](ol, StableTies())
+	for i := 0; i < 30; i++ {
+		tree = tree.Insert(ovr{i % 3, i})
+	}
+	group := tree.GetAll(tree.Cmp(ovr{i: 1}))
+	if len(group) != 10 {
+		t.Fatalf("Expected 10 items, got %d", len(group))
+	}
+	for i := range group {
+		if group[i].mark != i*3+1 {
+			t.Fatalf("Equal items are not in insertion order: %v", group)
+		}
+	}
+	res, deleted := tree.DeleteAll(tree.Cmp(ovr{i: 1}))
+	if deleted != 10 || res.Len() != 20 || res.Has(res.Cmp(ovr{i: 1})) {
+		t.Fatalf("DeleteAll removed %d items", deleted)
+	}
+	res.root.balanced(t)
+	if len(res.GetAll(res.Cmp(ovr{i: 1}))) != 0 {
+		t.Fatalf("Expected no items left")
+	}
+}
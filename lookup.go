@@ -0,0 +1,82 @@
+package ibtree
+
+import "sort"
+
+// sortedProbes returns a sorted copy of items.
+func (t *Tree[T]) sortedProbes(items []T) []T {
+	res := make([]T, len(items))
+	copy(res, items)
+	sort.SliceStable(res, func(i, j int) bool { return t.less(res[i], res[j]) })
+	return res
+}
+
+// fetchSorted looks up all the probes, which must be sorted, in the subtree rooted at n.
+// Every node in the subtree is visited at most once. fn is called in probe order
+// with each probe, the matching item in the Tree (if any), and whether a match was found.
+// If fn returns false, fetchSorted stops and returns false.
+func (t *Tree[T]) fetchSorted(n *node[T], probes []T, fn func(probe, item T, found bool) bool) bool {
+	if len(probes) == 0 {
+		return true
+	}
+	if n == nil {
+		var ref T
+		for i := range probes {
+			if !fn(probes[i], ref, false) {
+				return false
+			}
+		}
+		return true
+	}
+	lo := sort.Search(len(probes), func(i int) bool { return !t.less(probes[i], n.i) })
+	hi := lo + sort.Search(len(probes)-lo, func(i int) bool { return t.less(n.i, probes[lo+i]) })
+	if !t.fetchSorted(n.l, probes[:lo], fn) {
+		return false
+	}
+	for i := lo; i < hi; i++ {
+		if !fn(probes[i], n.i, true) {
+			return false
+		}
+	}
+	return t.fetchSorted(n.r, probes[hi:], fn)
+}
+
+// FetchMany looks up all of items in the Tree.  The items in the Tree that matched
+// are returned in found, and the items that did not match anything are returned
+// in missing.  Both are returned in sorted order.
+//
+// Instead of descending from the root once per item, FetchMany sorts a copy of items
+// and answers all the lookups in a single ordered traversal of the Tree.
+func (t *Tree[T]) FetchMany(items []T) (found, missing []T) {
+	t.FetchEach(items, func(probe, item T, ok bool) bool {
+		if ok {
+			found = append(found, item)
+		} else {
+			missing = append(missing, probe)
+		}
+		return true
+	})
+	return
+}
+
+// FetchEach is the callback form of FetchMany.  fn is called in sorted order once for each
+// of items with the item being looked up, the matching item in the Tree if there was one,
+// and whether a match was found.  FetchEach stops early if fn returns false.
+func (t *Tree[T]) FetchEach(items []T, fn func(probe, item T, found bool) bool) {
+	t.fetchSorted(t.root, t.sortedProbes(items), fn)
+}
+
+// ContainsAll returns true if every one of items is in the Tree.
+// It stops looking as soon as it finds an item that is missing.
+func (t *Tree[T]) ContainsAll(items ...T) bool {
+	return t.fetchSorted(t.root, t.sortedProbes(items), func(_, _ T, found bool) bool {
+		return found
+	})
+}
+
+// ContainsAny returns true if at least one of items is in the Tree.
+// It stops looking as soon as it finds one.
+func (t *Tree[T]) ContainsAny(items ...T) bool {
+	return !t.fetchSorted(t.root, t.sortedProbes(items), func(_, _ T, found bool) bool {
+		return !found
+	})
+}
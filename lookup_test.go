@@ -0,0 +1,63 @@
+package ibtree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestFetchMany(t *testing.T) {
+	tree := New[int](il, rand.Perm(100)...)
+	tree, _ = tree.DeleteItems(10, 20, 30)
+	found, missing := tree.FetchMany([]int{200, 30, 5, 99, 10, -1, 5})
+	if expect := []int{5, 5, 99}; !reflect.DeepEqual(expect, found) {
+		t.Fatalf("Expected found %v, got %v", expect, found)
+	}
+	if expect := []int{-1, 10, 30, 200}; !reflect.DeepEqual(expect, missing) {
+		t.Fatalf("Expected missing %v, got %v", expect, missing)
+	}
+	probes := rand.Perm(200)
+	found, missing = tree.FetchMany(probes)
+	if len(found) != 97 || len(missing) != 103 {
+		t.Fatalf("Expected 97 found and 103 missing, got %d and %d", len(found), len(missing))
+	}
+	for i := range found {
+		if _, ok := tree.Fetch(found[i]); !ok {
+			t.Fatalf("%d is not in the tree", found[i])
+		}
+	}
+	calls := 0
+	tree.FetchEach(probes, func(_, _ int, _ bool) bool {
+		calls++
+		return calls < 10
+	})
+	if calls != 10 {
+		t.Fatalf("FetchEach did not stop early")
+	}
+	found, missing = New[int](il).FetchMany([]int{1, 2})
+	if len(found) != 0 || len(missing) != 2 {
+		t.Fatalf("Empty tree lookups failed")
+	}
+}
+
+func TestContains(t *testing.T) {
+	tree := New[string](sl, "read", "write", "admin", "list")
+	if !tree.ContainsAll("write", "read") {
+		t.Fatalf("Expected to contain read and write")
+	}
+	if tree.ContainsAll("write", "delete", "read") {
+		t.Fatalf("Did not expect to contain delete")
+	}
+	if !tree.ContainsAll() {
+		t.Fatalf("Every tree contains nothing")
+	}
+	if !tree.ContainsAny("delete", "list") {
+		t.Fatalf("Expected to contain list")
+	}
+	if tree.ContainsAny("delete", "create") {
+		t.Fatalf("Did not expect to contain delete or create")
+	}
+	if tree.ContainsAny() {
+		t.Fatalf("Nothing cannot contain anything")
+	}
+}
@@ -0,0 +1,81 @@
+package ibtree
+
+import "sync/atomic"
+
+// maxDepth is one more than the deepest a lookup can ever go, since node
+// heights are recorded in a single byte.
+const maxDepth = 256
+
+// LookupStats collects the depth reached by every Get and Fetch (along with Has, GetOr, and FetchOr)
+// made against Trees it has been attached to with CollectLookups.  Hits and misses are tracked separately.
+// It is safe to use a LookupStats from multiple goroutines, and to share one between several Trees.
+type LookupStats struct {
+	hits, misses [maxDepth]uint64
+}
+
+// LookupHistogram is a snapshot of a LookupStats.  Hits[d] and Misses[d] are the number of
+// lookups that found or failed to find an item after examining d nodes.
+type LookupHistogram struct {
+	Hits, Misses []uint64
+}
+
+func (s *LookupStats) record(depth int, hit bool) {
+	if s == nil {
+		return
+	}
+	if hit {
+		atomic.AddUint64(&s.hits[depth], 1)
+	} else {
+		atomic.AddUint64(&s.misses[depth], 1)
+	}
+}
+
+func snapshotCounts(counts *[maxDepth]uint64) []uint64 {
+	res := make([]uint64, maxDepth)
+	last := 0
+	for i := range counts {
+		if res[i] = atomic.LoadUint64(&counts[i]); res[i] != 0 {
+			last = i + 1
+		}
+	}
+	return res[:last]
+}
+
+// Snapshot returns the lookups collected so far.  Trailing zero counts are trimmed off.
+func (s *LookupStats) Snapshot() LookupHistogram {
+	return LookupHistogram{Hits: snapshotCounts(&s.hits), Misses: snapshotCounts(&s.misses)}
+}
+
+// Reset discards all the lookups collected so far.
+func (s *LookupStats) Reset() {
+	for i := range s.hits {
+		atomic.StoreUint64(&s.hits[i], 0)
+		atomic.StoreUint64(&s.misses[i], 0)
+	}
+}
+
+func meanDepth(counts []uint64) (count uint64, mean float64) {
+	var total uint64
+	for depth, c := range counts {
+		count += c
+		total += c * uint64(depth)
+	}
+	if count > 0 {
+		mean = float64(total) / float64(count)
+	}
+	return
+}
+
+// Mean returns the average depth of hits and misses.  A mean is 0 if there were no lookups of that kind.
+func (h LookupHistogram) Mean() (hits, misses float64) {
+	_, hits = meanDepth(h.Hits)
+	_, misses = meanDepth(h.Misses)
+	return
+}
+
+// CollectLookups makes the Tree record the depth of its lookups in s.
+func CollectLookups(s *LookupStats) Option {
+	return func(o *options) {
+		o.lookups = s
+	}
+}
@@ -0,0 +1,48 @@
+package ibtree
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLookupStats(t *testing.T) {
+	stats := &LookupStats{}
+	n := 1 << 12
+	tree := NewWith[int](il, CollectLookups(stats)).InsertWith(func(f func(int)) {
+		for i := 0; i < n; i++ {
+			f(i * 2)
+		}
+	})
+	for i := 0; i < n; i++ {
+		tree.Fetch(i * 2)
+		tree.Has(tree.Cmp(i*2 + 1))
+	}
+	snap := stats.Snapshot()
+	var hits, misses uint64
+	for _, c := range snap.Hits {
+		hits += c
+	}
+	for _, c := range snap.Misses {
+		misses += c
+	}
+	if hits != uint64(n) || misses != uint64(n) {
+		t.Fatalf("Expected %d hits and misses, got %d and %d", n, hits, misses)
+	}
+	if snap.Hits[0] != 0 || snap.Misses[0] != 0 {
+		t.Fatalf("Lookups in a non-empty tree must look at at least one node")
+	}
+	hitAvg, missAvg := snap.Mean()
+	lg := math.Log2(float64(n))
+	if hitAvg > lg || missAvg < hitAvg || missAvg > 1.44*lg+1 {
+		t.Fatalf("Unexpected mean depths %f and %f", hitAvg, missAvg)
+	}
+	stats.Reset()
+	if snap = stats.Snapshot(); len(snap.Hits) != 0 || len(snap.Misses) != 0 {
+		t.Fatalf("Reset did not clear the stats")
+	}
+	tree.Fork().Fetch(0)
+	New[int](il).Fetch(0)
+	if snap = stats.Snapshot(); len(snap.Hits) == 0 || len(snap.Misses) != 0 {
+		t.Fatalf("Stats were not inherited correctly")
+	}
+}
@@ -0,0 +1,71 @@
+package ibtree
+
+// change is a single difference between two versions of a Tree.
+type change[T any] struct {
+	kind     int
+	from, to T
+}
+
+// key returns the item that places c in the Tree.
+func (c *change[T]) key() T {
+	if c.kind == diffAdded {
+		return c.to
+	}
+	return c.from
+}
+
+// sameChange returns whether a and b leave the Tree in the same state.
+func sameChange[T any](a, b *change[T], eq func(x, y T) bool) bool {
+	if a.kind == diffRemoved || b.kind == diffRemoved {
+		return a.kind == b.kind
+	}
+	return eq(a.to, b.to)
+}
+
+func (d *differ[T]) nextChange() (c change[T], ok bool) {
+	c.kind, c.from, c.to, ok = d.next()
+	return
+}
+
+// Merge3 merges a and b, which must both be descended from base, and returns the result.
+// All three Trees must be ordered the same way, and eq is used to tell whether an item
+// has been changed.  The result starts out as a, and then every change made between base
+// and b is applied to it, except for changes to items that were also changed between base
+// and a.  If both a and b made the same change to an item, it is kept.  Otherwise, resolve
+// is called with the item from base, the item from a, and the item from b.  Any of those
+// that do not exist, because the item was added or deleted, are passed as the zero value of T.
+// resolve returns the item to keep, or false to leave the item out.
+//
+// Subtrees shared between base and a or b are skipped, so Merge3 only does work proportional
+// to how much a and b have drifted from base.  The result shares nodes with a.
+// Trees created with StableTies are not supported.
+func Merge3[T any](base, a, b *Tree[T], eq func(x, y T) bool, resolve func(base, a, b T) (T, bool)) *Tree[T] {
+	da, db := newDiffer(base, a, eq), newDiffer(base, b, eq)
+	ca, okA := da.nextChange()
+	cb, okB := db.nextChange()
+	var ops []Op[T]
+	for okB {
+		switch {
+		case okA && a.less(ca.key(), cb.key()):
+			ca, okA = da.nextChange()
+			continue
+		case !okA || a.less(cb.key(), ca.key()):
+			if cb.kind == diffRemoved {
+				ops = append(ops, Op[T]{Kind: OpDelete, Item: cb.from})
+			} else {
+				ops = append(ops, Op[T]{Kind: OpInsert, Item: cb.to})
+			}
+		case !sameChange(&ca, &cb, eq):
+			if item, keep := resolve(ca.from, ca.to, cb.to); keep {
+				ops = append(ops, Op[T]{Kind: OpInsert, Item: item})
+			} else {
+				ops = append(ops, Op[T]{Kind: OpDelete, Item: ca.key()})
+			}
+			ca, okA = da.nextChange()
+		default:
+			ca, okA = da.nextChange()
+		}
+		cb, okB = db.nextChange()
+	}
+	return a.ApplyOps(ops)
+}
@@ -0,0 +1,68 @@
+package ibtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	items := make([]ovr, 100)
+	for i := range items {
+		items[i] = ovr{i: i * 2}
+	}
+	base := New[ovr](ol, items...)
+	eq := func(x, y ovr) bool { return x == y }
+	a := base.Insert(ovr{1, 1}, ovr{10, 1}, ovr{20, 1}, ovr{30, 1}, ovr{41, 1})
+	a, _, _ = a.Delete(ovr{i: 50})
+	a, _, _ = a.Delete(ovr{i: 60})
+	b := base.Insert(ovr{3, 2}, ovr{10, 2}, ovr{20, 1}, ovr{40, 2}, ovr{41, 2})
+	b, _, _ = b.Delete(ovr{i: 30})
+	b, _, _ = b.Delete(ovr{i: 60})
+	b, _, _ = b.Delete(ovr{i: 70})
+	conflicts := map[int][3]ovr{}
+	res := Merge3(base, a, b, eq, func(x, y, z ovr) (ovr, bool) {
+		conflicts[y.i+z.i] = [3]ovr{x, y, z}
+		if z.i == 30 || y.i == 30 {
+			return ovr{}, false
+		}
+		return ovr{y.i, 3}, true
+	})
+	res.root.balanced(t)
+	expect := map[int]int{1: 1, 3: 2, 10: 3, 20: 1, 40: 2, 41: 3}
+	for i := 0; i < 200; i += 2 {
+		if _, ok := expect[i]; !ok && i != 30 && i != 50 && i != 60 && i != 70 {
+			expect[i] = 0
+		}
+	}
+	if res.Len() != len(expect) {
+		t.Fatalf("Expected %d items, got %d", len(expect), res.Len())
+	}
+	for i, mark := range expect {
+		if v, found := res.Fetch(ovr{i: i}); !found || v.mark != mark {
+			t.Fatalf("Expected %d to be marked %d, got %v %v", i, mark, v, found)
+		}
+	}
+	// 10 changed on both sides, 30 changed on one and deleted on the other, 41 added on both.
+	if len(conflicts) != 3 || conflicts[20] != [3]ovr{{10, 0}, {10, 1}, {10, 2}} ||
+		conflicts[30] != [3]ovr{{30, 0}, {30, 1}, {}} || conflicts[82] != [3]ovr{{}, {41, 1}, {41, 2}} {
+		t.Fatalf("Unexpected conflicts %v", conflicts)
+	}
+	src := rand.New(rand.NewSource(8))
+	for k := 0; k < 20; k++ {
+		a, b := base, base
+		for i := 0; i < 30; i++ {
+			a = a.Insert(ovr{src.Intn(300), 1})
+			b, _, _ = b.Delete(ovr{i: src.Intn(300)})
+		}
+		res := Merge3(base, a, b, eq, func(x, y, z ovr) (ovr, bool) { return y, true })
+		res.root.balanced(t)
+		// With a resolver that always picks a, the result is a minus what only b deleted.
+		a.Walk(func(v ovr) bool {
+			_, inBase := base.Fetch(v)
+			if found := res.HasItem(v); found != (!inBase || b.HasItem(v) || v.mark == 1) {
+				t.Fatalf("Merge of %v is wrong", v)
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package ibtree
+
+import "container/heap"
+
+// mergeIter merges the items from several Iters into one ascending stream.
+type mergeIter[T any] struct {
+	less    LessThan[T]
+	resolve func(a, b T) T
+	trees   []*Tree[T]
+	iters   []Iter[T]
+	heads   []int // Indexes of the Iters that have an item waiting, kept as a heap.
+	item    T
+	ok      bool
+	started bool
+}
+
+func (m *mergeIter[T]) Len() int { return len(m.heads) }
+
+func (m *mergeIter[T]) Less(a, b int) bool {
+	x, y := m.iters[m.heads[a]].Item(), m.iters[m.heads[b]].Item()
+	switch {
+	case m.less(x, y):
+		return true
+	case m.less(y, x):
+		return false
+	}
+	// Equal items come out in the order their Trees were passed in.
+	return m.heads[a] < m.heads[b]
+}
+
+func (m *mergeIter[T]) Swap(a, b int) { m.heads[a], m.heads[b] = m.heads[b], m.heads[a] }
+
+func (m *mergeIter[T]) Push(x any) { m.heads = append(m.heads, x.(int)) }
+
+func (m *mergeIter[T]) Pop() any {
+	last := len(m.heads) - 1
+	res := m.heads[last]
+	m.heads = m.heads[:last]
+	return res
+}
+
+// take removes the smallest waiting item, and moves the Iter it came from along.
+func (m *mergeIter[T]) take() T {
+	i := m.heads[0]
+	res := m.iters[i].Item()
+	if m.iters[i].Next() {
+		heap.Fix(m, 0)
+	} else {
+		heap.Pop(m)
+	}
+	return res
+}
+
+// advance makes the smallest waiting item the current one, folding in any items
+// equal to it if there is a resolve function.
+func (m *mergeIter[T]) advance() bool {
+	if len(m.heads) == 0 {
+		m.Release()
+		return false
+	}
+	m.item, m.ok = m.take(), true
+	for m.resolve != nil && len(m.heads) > 0 && !m.less(m.item, m.iters[m.heads[0]].Item()) {
+		m.item = m.resolve(m.item, m.take())
+	}
+	return true
+}
+
+func (m *mergeIter[T]) Next() bool {
+	if !m.started {
+		m.started = true
+		for i := range m.iters {
+			if m.iters[i].Next() {
+				m.heads = append(m.heads, i)
+			}
+		}
+		heap.Init(m)
+	}
+	return m.advance()
+}
+
+// Prev is not supported by the Iter MergeIter returns.
+func (m *mergeIter[T]) Prev() bool {
+	return false
+}
+
+func (m *mergeIter[T]) Item() T {
+	if !m.ok {
+		panic("No iteration in progress")
+	}
+	return m.item
+}
+
+func (m *mergeIter[T]) Release() {
+	for i := range m.iters {
+		m.iters[i].Release()
+	}
+	var ref T
+	m.heads, m.item, m.ok, m.started = m.heads[:0], ref, false, true
+}
+
+// Rebase is not supported by the Iter MergeIter returns.
+func (m *mergeIter[T]) Rebase(*Tree[T]) bool {
+	return false
+}
+
+// Reset is not supported by the Iter MergeIter returns.
+func (m *mergeIter[T]) Reset(*Tree[T], Test[T], Test[T]) bool {
+	return false
+}
+
+// Seek seeks every merged Iter to cmp, and moves to the smallest of the items they land on.
+// Iters that have already run out are reset first, so Seek can move backwards as well.
+func (m *mergeIter[T]) Seek(cmp CompareAgainst[T]) bool {
+	if m.started && !m.ok {
+		return false
+	}
+	m.started = true
+	m.heads = m.heads[:0]
+	for i := range m.iters {
+		if m.iters[i].Reset(m.trees[i], nil, nil) && m.iters[i].Seek(cmp) {
+			m.heads = append(m.heads, i)
+		}
+	}
+	heap.Init(m)
+	return m.advance()
+}
+
+func (m *mergeIter[T]) Clone() Iter[T] {
+	res := *m
+	res.iters = make([]Iter[T], len(m.iters))
+	for i := range m.iters {
+		res.iters[i] = m.iters[i].Clone()
+	}
+	res.heads = append([]int(nil), m.heads...)
+	return &res
+}
+
+func (m *mergeIter[T]) Peek() (item T, found bool) {
+	if !m.started || m.resolve != nil {
+		return peekClone[T](m)
+	}
+	if len(m.heads) > 0 {
+		return m.iters[m.heads[0]].Item(), true
+	}
+	return
+}
+
+// MergeIter returns an Iter that lazily merges the items in trees, which must all be ordered
+// the same way, into a single ascending stream.  Only the smallest waiting item from each Tree
+// is looked at, using a heap, so merging k Trees costs O(log k) per item.
+//
+// If resolve is nil, every item from every Tree is returned, and equal items come out in
+// the order their Trees were passed in.  Otherwise, equal items are combined into one by
+// calling resolve on them in that order, with the result so far as a and the next item as b.
+// To keep the item from the first or the last Tree, have resolve return a or b.
+//
+// The Iter returned by MergeIter cannot run backwards, and cannot be rebased or reset.
+func MergeIter[T any](resolve func(a, b T) T, trees ...*Tree[T]) Iter[T] {
+	res := &mergeIter[T]{resolve: resolve, trees: trees, iters: make([]Iter[T], len(trees)), heads: make([]int, 0, len(trees))}
+	for i, t := range trees {
+		res.less = t.less
+		res.iters[i] = t.Iterator(nil, nil)
+	}
+	return res
+}
+
+// Join walks a and b, which must be ordered the same way, in tandem and calls onMatch
+// with every pair of equal items x from a and y from b in ascending order.  Neither Tree
+// is copied along the way, and only one Iter is used for each Tree.  If either Tree holds
+// several equal items, onMatch is called once for every combination of them, and the Iter
+// over b is moved back to the first of its equal items for each extra one from a.  Join
+// stops early if onMatch returns false.
+func Join[T any](a, b *Tree[T], onMatch func(x, y T) bool) {
+	ai, bi := a.Iterator(nil, nil), b.Iterator(nil, nil)
+	defer ai.Release()
+	defer bi.Release()
+	okA, okB := ai.Next(), bi.Next()
+	for okA && okB {
+		x, y := ai.Item(), bi.Item()
+		switch {
+		case a.less(x, y):
+			okA = ai.Next()
+		case a.less(y, x):
+			okB = bi.Next()
+		default:
+			for {
+				for okB = true; okB && !a.less(x, bi.Item()); okB = bi.Next() {
+					if !onMatch(x, bi.Item()) {
+						return
+					}
+				}
+				if okA = ai.Next(); !okA || a.less(y, ai.Item()) {
+					break
+				}
+				x = ai.Item()
+				// Go back to the first item in b equal to y.
+				bi.Reset(b, Lt(b.Cmp(y)), nil)
+				bi.Next()
+			}
+		}
+	}
+}
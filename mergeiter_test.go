@@ -0,0 +1,130 @@
+package ibtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMergeIter(t *testing.T) {
+	src := rand.New(rand.NewSource(61))
+	trees := make([]*Tree[ovr], 4)
+	all := map[int]int{}
+	total := 0
+	for i := range trees {
+		trees[i] = New[ovr](ol)
+		for _, v := range src.Perm(200)[:50] {
+			trees[i] = trees[i].Insert(ovr{v, i})
+			all[v]++
+			total++
+		}
+	}
+	iter := MergeIter(nil, trees...)
+	n, prev := 0, ovr{-1, -1}
+	for iter.Next() {
+		v := iter.Item()
+		if v.i < prev.i || (v.i == prev.i && v.mark <= prev.mark) {
+			t.Fatalf("Items out of order: %v after %v", v, prev)
+		}
+		if p, ok := iter.Peek(); ok && p.i < v.i {
+			t.Fatalf("Peek returned %v after %v", p, v)
+		}
+		prev = v
+		n++
+	}
+	if n != total {
+		t.Fatalf("Expected %d items, got %d", total, n)
+	}
+	last := MergeIter(func(a, b ovr) ovr { return ovr{a.i, a.mark + 10*b.mark} }, trees...)
+	n = 0
+	for last.Next() {
+		n++
+		if all[last.Item().i] == 0 {
+			t.Fatalf("Unexpected item %v", last.Item())
+		}
+	}
+	if n != len(all) {
+		t.Fatalf("Expected %d distinct items, got %d", len(all), n)
+	}
+	seek := MergeIter(nil, trees...)
+	if !seek.Seek(trees[0].Cmp(ovr{i: 100})) || seek.Item().i < 100 {
+		t.Fatalf("Seek failed")
+	}
+	clone := seek.Clone()
+	seek.Next()
+	if !clone.Next() || clone.Item() != seek.Item() {
+		t.Fatalf("Clone did not move independently")
+	}
+	low, high := New[ovr](ol), New[ovr](ol)
+	for i := 0; i < 10; i++ {
+		low, high = low.Insert(ovr{i, 0}), high.Insert(ovr{i + 20, 1})
+	}
+	back := MergeIter(nil, low, high)
+	for back.Next() && back.Item().i < 25 {
+	}
+	if !back.Seek(low.Cmp(ovr{i: 5})) || back.Item() != (ovr{5, 0}) {
+		t.Fatalf("Seeking backwards past an exhausted Iter failed")
+	}
+	for n = 1; back.Next(); n++ {
+	}
+	if n != 15 {
+		t.Fatalf("Expected 15 items after seeking back, got %d", n)
+	}
+	if MergeIter[int](nil).Next() {
+		t.Fatalf("Merging nothing should return nothing")
+	}
+}
+
+func TestMergeJoin(t *testing.T) {
+	a, b := New[int](il), New[int](il)
+	for i := 0; i < 300; i++ {
+		if i%2 == 0 {
+			a = a.Insert(i)
+		}
+		if i%3 == 0 {
+			b = b.Insert(i)
+		}
+	}
+	var got []int
+	Join(a, b, func(x, y int) bool {
+		if x != y {
+			t.Fatalf("Join matched %d with %d", x, y)
+		}
+		got = append(got, x)
+		return true
+	})
+	if len(got) != 50 {
+		t.Fatalf("Expected 50 matches, got %d", len(got))
+	}
+	for i, v := range got {
+		if v != i*6 {
+			t.Fatalf("Expected %d at %d, got %d", i*6, i, v)
+		}
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		Join(a, b, func(x, y int) bool { return true })
+	})
+	if allocs > 10 {
+		t.Fatalf("Join allocated %v times for 50 matches", allocs)
+	}
+	n := 0
+	Join(a, b, func(x, y int) bool { n++; return n < 5 })
+	if n != 5 {
+		t.Fatalf("Join did not stop early: %d", n)
+	}
+	sa, sb := NewWith[ovr](ol, StableTies()), NewWith[ovr](ol, StableTies())
+	for i := 0; i < 3; i++ {
+		sa = sa.Insert(ovr{1, i}, ovr{2, i})
+		sb = sb.Insert(ovr{2, i}, ovr{2, i + 3}, ovr{3, i})
+	}
+	n = 0
+	Join(sa, sb, func(x, y ovr) bool {
+		if x.i != 2 || y.i != 2 {
+			t.Fatalf("Join matched %v with %v", x, y)
+		}
+		n++
+		return true
+	})
+	if n != 18 {
+		t.Fatalf("Expected 18 pairs of duplicates, got %d", n)
+	}
+}
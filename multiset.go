@@ -0,0 +1,82 @@
+package ibtree
+
+// Multiset is an immutable ordered multiset.  It stores every distinct item once along with
+// the number of times it has been added, so equal items do not take up any extra space.
+// Like Set, every method that changes the contents of a Multiset returns a new one that
+// shares as much storage as possible with the original.
+type Multiset[T any] struct {
+	t     *Tree[Pair[T, int]]
+	total int
+}
+
+// NewMultiset allocates a new Multiset ordered by lt that holds items.
+func NewMultiset[T any](lt LessThan[T], items ...T) *Multiset[T] {
+	res := &Multiset[T]{t: New[Pair[T, int]](func(a, b Pair[T, int]) bool { return lt(a.First, b.First) })}
+	return res.Insert(items...)
+}
+
+func addCounts[T any](old, new Pair[T, int]) Pair[T, int] {
+	return MakePair(old.First, old.Second+new.Second)
+}
+
+// Tree returns the Tree backing the Multiset.  Each item in it is paired with its count.
+func (m *Multiset[T]) Tree() *Tree[Pair[T, int]] {
+	return m.t
+}
+
+// Len returns the total number of items in the Multiset, counting every copy of an item.
+func (m *Multiset[T]) Len() int {
+	return m.total
+}
+
+// Distinct returns the number of distinct items in the Multiset.
+func (m *Multiset[T]) Distinct() int {
+	return m.t.Len()
+}
+
+// Count returns the number of times item is in the Multiset.
+func (m *Multiset[T]) Count(item T) int {
+	v, _ := m.t.Fetch(Pair[T, int]{First: item})
+	return v.Second
+}
+
+// Insert returns a new Multiset with one more copy of each of items.
+func (m *Multiset[T]) Insert(items ...T) *Multiset[T] {
+	if len(items) == 0 {
+		return m
+	}
+	t := m.t.UpsertWith(func(add func(Pair[T, int])) {
+		for i := range items {
+			add(MakePair(items[i], 1))
+		}
+	}, addCounts[T])
+	return &Multiset[T]{t: t, total: m.total + len(items)}
+}
+
+// Delete returns a new Multiset with one copy of item removed, along with whether
+// item was in the Multiset.  Once the last copy of an item is removed, it is gone entirely.
+func (m *Multiset[T]) Delete(item T) (*Multiset[T], bool) {
+	t, found, _ := m.t.Update(m.t.Cmp(Pair[T, int]{First: item}), func(old Pair[T, int], ok bool) (Pair[T, int], bool) {
+		old.Second--
+		return old, old.Second > 0
+	})
+	if !found {
+		return m, false
+	}
+	return &Multiset[T]{t: t, total: m.total - 1}, true
+}
+
+// DeleteAll returns a new Multiset without any copies of item, along with how many were removed.
+func (m *Multiset[T]) DeleteAll(item T) (*Multiset[T], int) {
+	t, v, found := m.t.Delete(Pair[T, int]{First: item})
+	if !found {
+		return m, 0
+	}
+	return &Multiset[T]{t: t, total: m.total - v.Second}, v.Second
+}
+
+// Each calls fn with every distinct item in the Multiset and its count in ascending order,
+// stopping early if fn returns false.
+func (m *Multiset[T]) Each(fn func(item T, count int) bool) {
+	m.t.Walk(func(p Pair[T, int]) bool { return fn(p.First, p.Second) })
+}
@@ -0,0 +1,38 @@
+package ibtree
+
+import "testing"
+
+func TestMultiset(t *testing.T) {
+	m := NewMultiset[int](il, 3, 1, 3, 2, 3, 1)
+	if m.Len() != 6 || m.Distinct() != 3 {
+		t.Fatalf("Expected 6 items and 3 distinct ones, got %d and %d", m.Len(), m.Distinct())
+	}
+	for i, expect := range []int{0, 2, 1, 3} {
+		if m.Count(i) != expect {
+			t.Errorf("Expected %d copies of %d, got %d", expect, i, m.Count(i))
+		}
+	}
+	less, found := m.Delete(3)
+	if !found || less.Count(3) != 2 || less.Len() != 5 || m.Count(3) != 3 {
+		t.Fatalf("Delete did not decrement the count")
+	}
+	less, _ = less.Delete(2)
+	if less.Distinct() != 2 || less.Count(2) != 0 {
+		t.Fatalf("Deleting the last copy should remove the item")
+	}
+	if same, found := less.Delete(2); found || same != less {
+		t.Fatalf("Deleting a missing item should do nothing")
+	}
+	none, removed := m.DeleteAll(3)
+	if removed != 3 || none.Len() != 3 || none.Count(3) != 0 {
+		t.Fatalf("DeleteAll removed %d items", removed)
+	}
+	seen := 0
+	m.Each(func(item, count int) bool {
+		seen += count
+		return true
+	})
+	if seen != 6 {
+		t.Fatalf("Each saw %d items", seen)
+	}
+}
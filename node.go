@@ -5,6 +5,7 @@ type node[T any] struct {
 	l    *node[T] // left child
 	r    *node[T] // right child
 	genH uint64   // Generation and height of the node.
+	size int      // The number of nodes in the subtree rooted here, for order statistics.
 	i    T        // The item the node is holding.
 }
 
@@ -17,8 +18,12 @@ const (
 // nodeStack keeps track of nodes that are modified during insert and delete operations.
 // The node at position 0 is the root of the tree.
 type nodeStack[T any] struct {
-	s   []*node[T] // The stack of nodes we are currently manipulating.
-	gen uint64
+	s       []*node[T] // The stack of nodes we are currently manipulating.
+	gen     uint64
+	skew    int  // The largest height difference allowed between sibling subtrees.
+	rebuild bool // Whether to rebuild unbalanced subtrees instead of rotating them.
+	items   []T  // Scratch space for rebuilding subtrees.
+	guard   stackGuard
 }
 
 func (ns *nodeStack[T]) clear() {
@@ -26,14 +31,14 @@ func (ns *nodeStack[T]) clear() {
 }
 
 func (ns *nodeStack[T]) newNode(v T) *node[T] {
-	return &node[T]{i: v, genH: (ns.gen << hOffset) | 0x01}
+	return &node[T]{i: v, genH: (ns.gen << hOffset) | 0x01, size: 1}
 }
 
 func (ns *nodeStack[T]) copy(n *node[T]) *node[T] {
 	if n.gen() == ns.gen {
 		return n
 	}
-	return &node[T]{l: n.l, r: n.r, i: n.i, genH: (ns.gen << hOffset) | (n.h())}
+	return &node[T]{l: n.l, r: n.r, i: n.i, genH: (ns.gen << hOffset) | (n.h()), size: n.size}
 }
 
 func (ns *nodeStack[T]) add(n *node[T]) {
@@ -84,6 +89,22 @@ func (n *node[T]) h() uint64 {
 	return n.genH & hMask
 }
 
+// child returns the left child of n if left is true, and the right child otherwise.
+func (n *node[T]) child(left bool) *node[T] {
+	if left {
+		return n.l
+	}
+	return n.r
+}
+
+// height returns the height of the subtree rooted at n, which may be nil.
+func height[T any](n *node[T]) int {
+	if n == nil {
+		return 0
+	}
+	return int(n.h())
+}
+
 // balance calculates the relative balance of a node.
 // Negative numbers indicate a subtree that is left-heavy,
 // and positive numbers indicate a Tree that is right-heavy.
@@ -97,16 +118,19 @@ func (n *node[T]) balance() (res int) {
 	return
 }
 
-// setHeight calculates the height of this node.
+// setHeight calculates the height of this node, along with the size of the subtree rooted at it.
 func (n *node[T]) setHeight() {
 	h := uint64(0)
+	n.size = 1
 	if n.l != nil {
 		h = n.l.h()
+		n.size += n.l.size
 	}
 	if n.r != nil {
 		if rh := n.r.h(); rh >= h {
 			h = rh
 		}
+		n.size += n.r.size
 	}
 	h++
 	n.genH &= ^hMask
@@ -192,6 +216,125 @@ func (t *Tree[T]) getExact(ins *nodeStack[T], n *node[T], v T) int {
 	return Equal
 }
 
+// findExact is getExact without the copying.  It leaves the path to v in ins exactly
+// as it is in the Tree, so copyPath must be called before any of it is modified.
+func (t *Tree[T]) findExact(ins *nodeStack[T], n *node[T], v T) int {
+	ins.clear()
+	ins.s = append(ins.s, n)
+	for {
+		if t.less(n.i, v) {
+			if n.r == nil {
+				return Greater
+			}
+			n = n.r
+		} else if t.less(v, n.i) {
+			if n.l == nil {
+				return Less
+			}
+			n = n.l
+		} else {
+			return Equal
+		}
+		ins.s = append(ins.s, n)
+	}
+}
+
+// findCmp is findExact for a CompareAgainst.
+func (t *Tree[T]) findCmp(ins *nodeStack[T], n *node[T], cmp CompareAgainst[T]) int {
+	ins.clear()
+	ins.s = append(ins.s, n)
+	for {
+		switch cmp(n.i) {
+		case Less:
+			if n.r == nil {
+				return Greater
+			}
+			n = n.r
+		case Greater:
+			if n.l == nil {
+				return Less
+			}
+			n = n.l
+		case Equal:
+			return Equal
+		default:
+			panic(unorderable)
+		}
+		ins.s = append(ins.s, n)
+	}
+}
+
+// copyPath copies the nodes in ins that findExact left there and links the copies together.
+func (ns *nodeStack[T]) copyPath() {
+	for i, n := range ns.s {
+		c := ns.copy(n)
+		if i > 0 {
+			if p := ns.s[i-1]; p.l == n {
+				p.l = c
+			} else {
+				p.r = c
+			}
+		}
+		ns.s[i] = c
+	}
+}
+
+// getAfter is getExact for Trees that keep equal items.  It never stops at
+// an equal item, instead it walks to the right of it so that v will be
+// placed after every item already in the Tree that it is equal to.
+func (t *Tree[T]) getAfter(ins *nodeStack[T], n *node[T], v T) int {
+	ins.clear()
+	ins.add(n)
+	for {
+		if t.less(v, n.i) {
+			if n.l == nil {
+				return Less
+			}
+			ins.addLeft(n.l)
+			n = n.l
+		} else {
+			if n.r == nil {
+				return Greater
+			}
+			ins.addRight(n.r)
+			n = n.r
+		}
+	}
+}
+
+// getFirst is getExact for Trees that keep equal items.  It leaves the
+// leftmost item equal to v (which is also the oldest one) at the top of ins.
+func (t *Tree[T]) getFirst(ins *nodeStack[T], n *node[T], v T) int {
+	ins.clear()
+	ins.add(n)
+	found := 0
+	for {
+		if t.less(n.i, v) {
+			if n.r == nil {
+				break
+			}
+			ins.addRight(n.r)
+			n = n.r
+		} else {
+			if !t.less(v, n.i) {
+				found = len(ins.s)
+			}
+			if n.l == nil {
+				break
+			}
+			ins.addLeft(n.l)
+			n = n.l
+		}
+	}
+	if found == 0 {
+		return Less
+	}
+	for len(ins.s) > found {
+		ins.drop()
+	}
+	return Equal
+}
+
 func (n *node[T]) getLeftmost(res *nodeStack[T]) {
 	res.addRight(n.r)
 	n = n.r
@@ -237,56 +380,97 @@ func (n *node[T]) swapChild(was, is *node[T]) *node[T] {
 	return is
 }
 
-// rebalance walks up the Tree starting at node n, rebalancing nodes
-// that no longer meet the AVL balance criteria. rebalance will continue until
-// it either walks all the way up the Tree, or the node has the
-// same height it started with.
-func rebalance[T any](ins *nodeStack[T]) {
-	var n *node[T]
-	for i := len(ins.s) - 1; i >= 0; i-- {
-		n = ins.s[i]
-		oh := n.h()
-		switch n.balance() {
-		case Less, Equal, Greater:
-		case rightHeavy:
+// fix restores the balance of the subtree rooted at n, which must already be
+// owned by ns.  The children of n must be balanced, and their heights must be correct.
+// fix returns the new root of the subtree with its height set.
+func (ns *nodeStack[T]) fix(n *node[T]) *node[T] {
+	for {
+		switch b := n.balance(); {
+		case b > ns.skew:
 			// Tree is excessively right-heavy, rotate it to the left.
-			n.r = ins.copy(n.r)
+			n.r = ns.copy(n.r)
 			if n.r.balance() < 0 {
-				n.r.l = ins.copy(n.r.l)
+				n.r.l = ns.copy(n.r.l)
 				// Right Tree is left-heavy, which would cause the next rotation to result in overall left-heaviness.
 				// Rotate the right Tree to the right to counteract this.
 				n.r = n.r.rotateRight()
-				n.r.r.setHeight()
+				n.r.r = ns.fix(n.r.r)
 			}
-			if i > 0 {
-				n = ins.s[i-1].swapChild(n, n.rotateLeft())
-			} else {
-				n = n.rotateLeft()
-			}
-			n.l.setHeight()
-		case leftHeavy:
+			n = n.rotateLeft()
+			n.l = ns.fix(n.l)
+		case b < -ns.skew:
 			// Tree is excessively left-heavy, rotate it to the right
-			n.l = ins.copy(n.l)
+			n.l = ns.copy(n.l)
 			if n.l.balance() > 0 {
-				n.l.r = ins.copy(n.l.r)
+				n.l.r = ns.copy(n.l.r)
 				// The left Tree is right-heavy, which would cause the next rotation to result in overall right-heaviness.
 				// Rotate the left Tree to the left to compensate.
 				n.l = n.l.rotateLeft()
-				n.l.l.setHeight()
+				n.l.l = ns.fix(n.l.l)
 			}
+			n = n.rotateRight()
+			n.r = ns.fix(n.r)
+		default:
+			n.setHeight()
+			return n
+		}
+	}
+}
+
+// rebuildSubtree replaces the subtree rooted at n with a perfectly balanced copy of itself.
+func (ns *nodeStack[T]) rebuildSubtree(n *node[T]) *node[T] {
+	ns.items = appendItems(ns.items[:0], n)
+	res := buildSorted(ns.items, ns.gen)
+	var ref T
+	for i := range ns.items {
+		ns.items[i] = ref
+	}
+	return res
+}
+
+// appendItems appends all the items in the subtree rooted at n to items in order.
+func appendItems[T any](items []T, n *node[T]) []T {
+	for n != nil {
+		items = appendItems(items, n.l)
+		items = append(items, n.i)
+		n = n.r
+	}
+	return items
+}
+
+// restore brings the subtree rooted at n back into balance according to the
+// balancing policy ns was created with.
+func (ns *nodeStack[T]) restore(n *node[T]) *node[T] {
+	if b := n.balance(); ns.rebuild && (b > ns.skew || b < -ns.skew) {
+		return ns.rebuildSubtree(n)
+	}
+	return ns.fix(n)
+}
+
+// rebalance walks up the Tree starting at node n, rebalancing nodes
+// that no longer meet the balance criteria.  Once a node has the same height
+// it started with, nothing above it can be out of balance, but rebalance still
+// walks the rest of the way up the Tree to fix up subtree sizes.
+func rebalance[T any](ins *nodeStack[T]) {
+	var n *node[T]
+	settled := false
+	for i := len(ins.s) - 1; i >= 0; i-- {
+		n = ins.s[i]
+		if settled {
+			n.setHeight()
+			continue
+		}
+		oh := n.h()
+		if b := n.balance(); b > ins.skew || b < -ins.skew {
 			if i > 0 {
-				n = ins.s[i-1].swapChild(n, n.rotateRight())
+				n = ins.s[i-1].swapChild(n, ins.restore(n))
 			} else {
-				n = n.rotateRight()
+				n = ins.restore(n)
 			}
-			n.r.setHeight()
-		default:
-			panic("Tree too far out of shape!")
-		}
-		ins.s[i] = n
-		n.setHeight()
-		if oh == n.h() {
-			break
+			ins.s[i] = n
+		} else {
+			n.setHeight()
 		}
+		settled = oh == n.h()
 	}
 }
@@ -0,0 +1,123 @@
+package ibtree
+
+import (
+	"cmp"
+	"iter"
+	"sort"
+)
+
+// OrderedMap is an immutable sorted map from keys to values backed by a Tree of Pairs.
+// Entries are ordered by their keys alone, so values never need to be comparable and are
+// never looked at when searching.  Like Set, every method that changes the contents of an
+// OrderedMap returns a new one that shares as much storage as possible with the original.
+// Keys are compared with cmp.Compare, so a NaN key sorts before every other float and is
+// equal to any other NaN.
+type OrderedMap[K cmp.Ordered, V any] struct {
+	t *Tree[Pair[K, V]]
+}
+
+func lessKey[K cmp.Ordered, V any](a, b Pair[K, V]) bool {
+	return cmp.Less(a.First, b.First)
+}
+
+func cmpKey[K cmp.Ordered, V any](entry Pair[K, V], key K) int {
+	return cmp.Compare(entry.First, key)
+}
+
+// NewOrderedMap allocates a new empty OrderedMap.
+func NewOrderedMap[K cmp.Ordered, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{t: New[Pair[K, V]](lessKey[K, V])}
+}
+
+// Tree returns the Tree backing the OrderedMap.
+func (m *OrderedMap[K, V]) Tree() *Tree[Pair[K, V]] {
+	return m.t
+}
+
+// Len returns the number of entries in the OrderedMap.
+func (m *OrderedMap[K, V]) Len() int {
+	return m.t.Len()
+}
+
+// Get returns the value stored under key and true, or a zero V and false if there is none.
+func (m *OrderedMap[K, V]) Get(key K) (val V, found bool) {
+	entry, found := GetKey(m.t, key, cmpKey[K, V])
+	return entry.Second, found
+}
+
+// Has returns whether there is a value stored under key.
+func (m *OrderedMap[K, V]) Has(key K) bool {
+	return HasKey(m.t, key, cmpKey[K, V])
+}
+
+// Set returns a new OrderedMap with val stored under key, replacing any value already there.
+func (m *OrderedMap[K, V]) Set(key K, val V) *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{t: m.t.Insert(MakePair(key, val))}
+}
+
+// Delete returns a new OrderedMap without key, along with the value that was stored
+// under it and whether there was one.
+func (m *OrderedMap[K, V]) Delete(key K) (res *OrderedMap[K, V], val V, found bool) {
+	t, entry, found := m.t.Delete(Pair[K, V]{First: key})
+	return &OrderedMap[K, V]{t: t}, entry.Second, found
+}
+
+// Range calls fn with every key from lo up to but not including hi and its value in
+// ascending order, stopping early if fn returns false.
+func (m *OrderedMap[K, V]) Range(lo, hi K, fn func(key K, val V) bool) {
+	m.t.Range(
+		func(entry Pair[K, V]) bool { return cmp.Less(entry.First, lo) },
+		func(entry Pair[K, V]) bool { return !cmp.Less(entry.First, hi) },
+		func(entry Pair[K, V]) bool { return fn(entry.First, entry.Second) })
+}
+
+// Each calls fn with every key and its value in ascending order, stopping early if fn returns false.
+func (m *OrderedMap[K, V]) Each(fn func(key K, val V) bool) {
+	m.t.Walk(func(entry Pair[K, V]) bool { return fn(entry.First, entry.Second) })
+}
+
+// Keys returns an iter.Seq that yields every key in the OrderedMap in ascending order.
+func (m *OrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		ascend(m.t.root, nil, nil, func(entry Pair[K, V]) bool { return yield(entry.First) })
+	}
+}
+
+// Values returns an iter.Seq that yields every value in the OrderedMap in ascending order of their keys.
+func (m *OrderedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		ascend(m.t.root, nil, nil, func(entry Pair[K, V]) bool { return yield(entry.Second) })
+	}
+}
+
+// All returns an iter.Seq2 that yields every key and its value in ascending order, the
+// same way maps.All does for a built-in map.  maps.Collect(m.All()) turns the OrderedMap
+// back into a built-in map.
+func (m *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		ascend(m.t.root, nil, nil, func(entry Pair[K, V]) bool { return yield(entry.First, entry.Second) })
+	}
+}
+
+// Collect builds a new OrderedMap out of the keys and values seq yields, the same way
+// maps.Collect builds a built-in map.  If seq yields a key more than once, the last value
+// yielded for it is kept.  As long as seq yields its keys in ascending order, the OrderedMap
+// is built directly from them without any rebalancing.
+func Collect[K cmp.Ordered, V any](seq iter.Seq2[K, V]) *OrderedMap[K, V] {
+	l := newLoader(New[Pair[K, V]](lessKey[K, V]))
+	for k, v := range seq {
+		l.add(MakePair(k, v))
+	}
+	return &OrderedMap[K, V]{t: l.finish()}
+}
+
+// FromMap builds a new OrderedMap holding the same keys and values as src.  The entries
+// are sorted first, so the OrderedMap is built in a single pass without any rebalancing.
+func FromMap[K cmp.Ordered, V any](src map[K]V) *OrderedMap[K, V] {
+	entries := make([]Pair[K, V], 0, len(src))
+	for k, v := range src {
+		entries = append(entries, MakePair(k, v))
+	}
+	sort.Slice(entries, func(i, j int) bool { return cmp.Less(entries[i].First, entries[j].First) })
+	return &OrderedMap[K, V]{t: NewFromSorted(lessKey[K, V], entries)}
+}
@@ -0,0 +1,120 @@
+package ibtree
+
+import (
+	"maps"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestOrderedMap(t *testing.T) {
+	m := NewOrderedMap[int, string]()
+	for _, i := range rand.Perm(100) {
+		m = m.Set(i, strconv.Itoa(i))
+	}
+	orig := m.Set(5, "five")
+	if v, _ := orig.Get(5); v != "five" || orig.Len() != 100 {
+		t.Fatalf("Set did not replace the value, got %q", v)
+	}
+	if v, _ := m.Get(5); v != "5" {
+		t.Fatalf("Set modified the original OrderedMap")
+	}
+	m, v, found := m.Delete(50)
+	if !found || v != "50" || m.Has(50) || m.Len() != 99 {
+		t.Fatalf("Delete failed, got %q %v", v, found)
+	}
+	if _, _, found = m.Delete(50); found {
+		t.Fatalf("Deleted 50 twice")
+	}
+	keys := []int{}
+	m.Range(45, 55, func(k int, v string) bool {
+		if v != strconv.Itoa(k) {
+			t.Fatalf("Key %d has value %q", k, v)
+		}
+		keys = append(keys, k)
+		return true
+	})
+	if len(keys) != 9 || keys[0] != 45 || keys[8] != 54 {
+		t.Fatalf("Unexpected range %v", keys)
+	}
+	n := 0
+	m.Each(func(int, string) bool {
+		n++
+		return n < 10
+	})
+	if n != 10 {
+		t.Fatalf("Each did not stop early")
+	}
+}
+
+func TestOrderedMapSeq(t *testing.T) {
+	src := map[int]string{}
+	for _, i := range rand.Perm(50) {
+		src[i] = strconv.Itoa(i)
+	}
+	m := FromMap(src)
+	if m.Len() != 50 {
+		t.Fatalf("Expected 50 entries, got %d", m.Len())
+	}
+	m.Tree().root.balanced(t)
+	keys := slices.Collect(m.Keys())
+	if !slices.IsSorted(keys) || len(keys) != 50 || keys[0] != 0 || keys[49] != 49 {
+		t.Fatalf("Keys out of order: %v", keys)
+	}
+	for i, v := range slices.Collect(m.Values()) {
+		if v != strconv.Itoa(i) {
+			t.Fatalf("Expected value %d to be %q, got %q", i, strconv.Itoa(i), v)
+		}
+	}
+	if back := maps.Collect(m.All()); !maps.Equal(back, src) {
+		t.Fatalf("Round trip through a built-in map failed")
+	}
+	n := 0
+	for range m.All() {
+		if n++; n == 5 {
+			break
+		}
+	}
+	if n != 5 {
+		t.Fatalf("All did not stop early")
+	}
+	c := Collect(m.All())
+	if c.Len() != 50 {
+		t.Fatalf("Expected 50 entries, got %d", c.Len())
+	}
+	c = Collect(func(yield func(int, string) bool) {
+		for _, kv := range []Pair[int, string]{{3, "a"}, {1, "b"}, {3, "c"}} {
+			if !yield(kv.First, kv.Second) {
+				return
+			}
+		}
+	})
+	if v, _ := c.Get(3); c.Len() != 2 || v != "c" {
+		t.Fatalf("Collect should keep the last value for a key, got %q", v)
+	}
+}
+
+func TestOrderedMapNaN(t *testing.T) {
+	m := NewOrderedMap[float64, int]()
+	for i, k := range []float64{3, math.NaN(), 1, math.Inf(-1), 2, math.NaN()} {
+		m = m.Set(k, i)
+	}
+	m.Tree().root.balanced(t)
+	if m.Len() != 5 {
+		t.Fatalf("Expected 5 entries, got %d", m.Len())
+	}
+	if v, found := m.Get(math.NaN()); !found || v != 5 {
+		t.Fatalf("Expected NaN to map to 5, got %d %v", v, found)
+	}
+	for _, k := range []float64{1, 2, 3, math.Inf(-1)} {
+		if !m.Has(k) {
+			t.Fatalf("Lost key %v", k)
+		}
+	}
+	keys := slices.Collect(m.Keys())
+	if !math.IsNaN(keys[0]) || keys[1] != math.Inf(-1) || keys[4] != 3 {
+		t.Fatalf("Unexpected key order %v", keys)
+	}
+}
@@ -0,0 +1,63 @@
+package ibtree
+
+// OpKind is the kind of change an Op makes to a Tree.
+type OpKind int
+
+const (
+	// OpInsert inserts Item, replacing any item equal to it.
+	OpInsert OpKind = iota
+	// OpDelete deletes the item equal to Item, if there is one.
+	OpDelete
+	// OpReplace replaces the item equal to Item, but only if there is one.
+	OpReplace
+)
+
+// Op is a single change in a batch passed to ApplyOps.
+type Op[T any] struct {
+	Kind OpKind
+	Item T
+}
+
+// replaceOne replaces the item equal to item with item if there is one.
+func (t *Tree[T]) replaceOne(ins *nodeStack[T], item T) (replaced T, found bool) {
+	defer t.enterWrite(ins)()
+	if t.root == nil {
+		return
+	}
+	var direction int
+	if t.opts.stable {
+		direction = t.getFirst(ins, t.root, item)
+	} else {
+		direction = t.getExact(ins, t.root, item)
+	}
+	if found = direction == Equal; !found {
+		return
+	}
+	n := ins.at(-1)
+	replaced, n.i = n.i, item
+	t.root = ins.at(0)
+	return
+}
+
+// ApplyOps returns a new Tree with all of ops applied to it in order.  Unlike calling
+// InsertWith and then DeleteWith, the whole batch is applied in a single copy-on-write pass
+// and only one new Tree is created. t and the new Tree will share nodes where possible.
+func (t *Tree[T]) ApplyOps(ops []Op[T]) *Tree[T] {
+	res := t.Fork()
+	ins := res.getNsp()
+	defer res.putNsp(ins)
+	for i := range ops {
+		switch ops[i].Kind {
+		case OpInsert:
+			res.insertOne(ins, ops[i].Item)
+		case OpDelete:
+			res.deleteOne(ins, ops[i].Item)
+		case OpReplace:
+			res.replaceOne(ins, ops[i].Item)
+		default:
+			panic("Unknown OpKind passed to ApplyOps")
+		}
+	}
+	res.compactIfNeeded(ins)
+	return res
+}
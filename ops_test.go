@@ -0,0 +1,36 @@
+package ibtree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyOps(t *testing.T) {
+	tree := New[ovr](ol, ovr{1, 0}, ovr{2, 0}, ovr{3, 0})
+	res := tree.ApplyOps([]Op[ovr]{
+		{Kind: OpInsert, Item: ovr{4, 1}},
+		{Kind: OpDelete, Item: ovr{i: 1}},
+		{Kind: OpReplace, Item: ovr{2, 1}},
+		{Kind: OpReplace, Item: ovr{5, 1}},
+		{Kind: OpInsert, Item: ovr{1, 1}},
+		{Kind: OpDelete, Item: ovr{i: 3}},
+	})
+	res.root.balanced(t)
+	got := []ovr{}
+	res.Walk(func(o ovr) bool {
+		got = append(got, o)
+		return true
+	})
+	if expect := []ovr{{1, 1}, {2, 1}, {4, 1}}; !reflect.DeepEqual(expect, got) {
+		t.Fatalf("Expected %v, got %v", expect, got)
+	}
+	if tree.Len() != 3 {
+		t.Fatalf("Original tree was modified")
+	}
+	if v, _ := tree.Fetch(ovr{i: 2}); v.mark != 0 {
+		t.Fatalf("Original tree was modified")
+	}
+	if New[ovr](ol).ApplyOps([]Op[ovr]{{Kind: OpReplace, Item: ovr{}}}).Len() != 0 {
+		t.Fatalf("Replace inserted into an empty tree")
+	}
+}
@@ -0,0 +1,84 @@
+package ibtree
+
+import "sync"
+
+// Option configures optional behaviour for a Tree created with NewWith.
+// Options are inherited by every Tree derived from the one they were
+// applied to.
+type Option func(*options)
+
+type options struct {
+	stable   bool
+	balancer Balancer
+	lookups  *LookupStats
+	// compactRatio is how many deleted items per remaining item trigger a rebuild.
+	compactRatio float64
+	pool         poolScope
+}
+
+// poolScope controls which Trees share a pool of scratch nodeStacks.
+type poolScope int
+
+const (
+	sharedPool poolScope = iota // Shared by a Tree and every Tree derived from it.
+	treePool                    // Each Tree gets its own pool.
+	noPool                      // Scratch space is allocated for each operation and then dropped.
+)
+
+// StableTies makes the Tree keep every item inserted into it, even
+// items that compare equal to items already in the Tree.  Equal items are kept
+// in the order they were inserted, just as if each item carried a monotonically
+// increasing sequence number that was used as the final tie-breaker.
+// That order is preserved by every Tree derived from this one.
+//
+// Delete and friends will remove the oldest of the equal items, and Get and Fetch
+// will return one of them.  Use GetAll and DeleteAll to work with all of them at once,
+// which turns the Tree into a multimap for non-unique keys.
+func StableTies() Option {
+	return func(o *options) {
+		o.stable = true
+	}
+}
+
+// PoolPerTree gives every Tree its own pool of the scratch space used while changing it,
+// instead of sharing one pool between a Tree and every Tree derived from it by Fork,
+// Reverse, SortBy, Bud, Insert, Delete and friends.
+func PoolPerTree() Option {
+	return func(o *options) {
+		o.pool = treePool
+	}
+}
+
+// NoPool makes the Tree allocate fresh scratch space for every operation that changes
+// it, and drop it once the operation finishes, instead of keeping it in a sync.Pool.
+// Scratch space is always cleared before it is dropped or pooled, so it never holds
+// on to items after an operation finishes.
+func NoPool() Option {
+	return func(o *options) {
+		o.pool = noPool
+	}
+}
+
+// newPool makes the pool of scratch nodeStacks for a new Tree.  shared is the pool of the
+// Tree the new one is derived from, if any.
+func newPool[T any](o options, shared *sync.Pool) *sync.Pool {
+	switch {
+	case o.pool == noPool:
+		return nil
+	case o.pool == sharedPool && shared != nil:
+		return shared
+	default:
+		return &sync.Pool{New: func() any { return &nodeStack[T]{} }}
+	}
+}
+
+// NewWith allocates a new empty Tree that will keep itself ordered according to the
+// passed in LessThan, and that has opts applied to it.
+func NewWith[T any](lt LessThan[T], opts ...Option) *Tree[T] {
+	res := &Tree[T]{less: lt}
+	for _, opt := range opts {
+		opt(&res.opts)
+	}
+	res.nsp = newPool[T](res.opts, nil)
+	return res
+}
@@ -0,0 +1,82 @@
+package ibtree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestStableTies(t *testing.T) {
+	tree := NewWith[ovr](ol, StableTies())
+	tree = tree.Insert(ovr{2, 0}, ovr{1, 1}, ovr{2, 2}, ovr{0, 3}, ovr{2, 4})
+	tree = tree.InsertWith(func(f func(ovr)) {
+		for i := 5; i < 100; i++ {
+			f(ovr{i % 3, i})
+		}
+	})
+	tree.root.balanced(t)
+	if tree.Len() != 100 {
+		t.Fatalf("Expected 100 items, got %d", tree.Len())
+	}
+	check := func(tree *Tree[ovr]) {
+		t.Helper()
+		last := ovr{-1, -1}
+		iter := tree.All()
+		for iter.Next() {
+			item := iter.Item()
+			if item.i < last.i || (item.i == last.i && item.mark <= last.mark) {
+				t.Fatalf("%v came after %v", item, last)
+			}
+			last = item
+		}
+	}
+	check(tree)
+	check(tree.Fork())
+	check(tree.SortedClone(func(a, b ovr) bool { return false }))
+	tree, deleted, found := tree.Delete(ovr{i: 2})
+	if !found || !reflect.DeepEqual(deleted, ovr{2, 0}) {
+		t.Fatalf("Expected to delete the oldest item, got %v", deleted)
+	}
+	tree, deleted, found = tree.Delete(ovr{i: 2})
+	if !found || !reflect.DeepEqual(deleted, ovr{2, 2}) {
+		t.Fatalf("Expected to delete the oldest item, got %v", deleted)
+	}
+	tree.root.balanced(t)
+	check(tree)
+	if _, _, found = tree.Delete(ovr{i: 7}); found {
+		t.Fatalf("Deleted a nonexistent item")
+	}
+}
+
+func TestPoolOptions(t *testing.T) {
+	shared := New[int](il, 1, 2, 3)
+	if shared.Fork().nsp != shared.nsp || shared.Reverse().nsp != shared.nsp {
+		t.Fatalf("Derived Trees should share a pool by default")
+	}
+	perTree := NewWith[int](il, PoolPerTree()).Insert(1, 2, 3)
+	if perTree.nsp == nil || perTree.Fork().nsp == perTree.nsp || perTree.SortBy(il).nsp == perTree.nsp {
+		t.Fatalf("Each Tree should get its own pool")
+	}
+	none := NewWith[int](il, NoPool()).Insert(3, 2, 1)
+	if none.nsp != nil || none.Fork().nsp != nil {
+		t.Fatalf("Expected no pool")
+	}
+	for _, tree := range []*Tree[int]{perTree, none} {
+		tree = tree.Insert(rand.Perm(100)...)
+		tree, _ = tree.DeleteItems(5, 6, 7)
+		tree.root.balanced(t)
+		if tree.Len() != 97 {
+			t.Fatalf("Expected 97 items, got %d", tree.Len())
+		}
+	}
+	ns := shared.getNsp()
+	ns.add(shared.root)
+	ns.addLeft(shared.root.l)
+	ns.clear()
+	shared.putNsp(ns)
+	for _, n := range ns.s[:cap(ns.s)] {
+		if n != nil {
+			t.Fatalf("putNsp left a node behind in the scratch space")
+		}
+	}
+}
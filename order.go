@@ -0,0 +1,167 @@
+package ibtree
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Every node keeps track of the size of the subtree rooted at it, which lets the
+// functions in this file work in O(log n) time instead of walking the Tree.
+
+// Rank returns the zero-based position in the Tree of the first item equal to the reference
+// cmp wraps, along with true.  If there is no such item, Rank returns the position it
+// would be inserted at and false.  Either way, the returned position is the number of
+// items in the Tree that are less than the reference.
+func (t *Tree[T]) Rank(cmp CompareAgainst[T]) (pos int, found bool) {
+	for n := t.root; n != nil; {
+		switch cmp(n.i) {
+		case Greater:
+			n = n.l
+		case Less:
+			pos += countNodes(n.l) + 1
+			n = n.r
+		case Equal:
+			found = true
+			n = n.l
+		default:
+			panic(unorderable)
+		}
+	}
+	return
+}
+
+// At returns the item at zero-based position index in the Tree, along with true.
+// If index is out of range, At returns the zero value of T and false.
+func (t *Tree[T]) At(index int) (item T, found bool) {
+	if index < 0 || index >= t.count {
+		return
+	}
+	for n := t.root; n != nil; {
+		switch lc := countNodes(n.l); {
+		case index < lc:
+			n = n.l
+		case index > lc:
+			index -= lc + 1
+			n = n.r
+		default:
+			return n.i, true
+		}
+	}
+	return
+}
+
+// Quantile returns the item at quantile q of the Tree, which is the item at position
+// floor(q*(Len()-1)), along with true.  Quantile(0) is the smallest item, and Quantile(1)
+// is the largest.  If the Tree is empty or q is not between 0 and 1, Quantile returns
+// the zero value of T and false.
+func (t *Tree[T]) Quantile(q float64) (item T, found bool) {
+	if t.count == 0 || !(q >= 0 && q <= 1) {
+		return
+	}
+	return t.At(int(math.Floor(q * float64(t.count-1))))
+}
+
+// Median returns the middle item in the Tree, along with true.  If the Tree holds an even
+// number of items, the lower of the two middle items is returned.  If the Tree is empty,
+// Median returns the zero value of T and false.
+func (t *Tree[T]) Median() (item T, found bool) {
+	return t.At((t.count - 1) / 2)
+}
+
+// countWhile returns the number of items at the start of the subtree rooted at n
+// that test returns true for.  test must return true for all the items up to some
+// point, and false for the rest.
+func countWhile[T any](n *node[T], test Test[T]) (res int) {
+	for n != nil {
+		if test(n.i) {
+			res += countNodes(n.l) + 1
+			n = n.r
+		} else {
+			n = n.l
+		}
+	}
+	return
+}
+
+// CountRange returns the number of items that Range would visit with the same start
+// and stop, without visiting any of them.  Either start or stop may be nil.
+func (t *Tree[T]) CountRange(start, stop Test[T]) int {
+	res := t.count
+	if start != nil {
+		res -= countWhile(t.root, start)
+	}
+	if stop != nil {
+		res -= t.count - countWhile(t.root, func(item T) bool { return !stop(item) })
+	}
+	if res < 0 {
+		return 0
+	}
+	return res
+}
+
+// DeleteAt returns a new Tree with the item at zero-based position index removed, along
+// with the removed item and true.  If index is out of range, DeleteAt returns t, the zero
+// value of T, and false.  The original Tree is left unchanged, and the returned Tree will
+// share nodes with it where possible.
+func (t *Tree[T]) DeleteAt(index int) (into *Tree[T], deleted T, found bool) {
+	if index < 0 || index >= t.count {
+		return t, deleted, false
+	}
+	into = t.Fork()
+	ins := into.getNsp()
+	defer into.putNsp(ins)
+	defer into.enterWrite(ins)()
+	ins.clear()
+	ins.add(into.root)
+	for n := into.root; ; {
+		lc := countNodes(n.l)
+		if index == lc {
+			break
+		}
+		if index < lc {
+			ins.addLeft(n.l)
+			n = n.l
+		} else {
+			index -= lc + 1
+			ins.addRight(n.r)
+			n = n.r
+		}
+	}
+	return into, into.removeTop(ins), true
+}
+
+// Sample returns k items picked uniformly at random from the Tree, without picking any
+// item twice, in ascending order.  The positions of the items are chosen first, and then
+// each one is fetched with At, so Sample takes O(k log n) time no matter how big the
+// Tree is.  If k is at least the number of items in the Tree, every item is returned.
+// If rng is nil, the top-level functions in math/rand are used instead.
+func (t *Tree[T]) Sample(k int, rng *rand.Rand) []T {
+	if k >= t.count {
+		return t.Items()
+	}
+	if k <= 0 {
+		return nil
+	}
+	intn := rand.Intn
+	if rng != nil {
+		intn = rng.Intn
+	}
+	// Floyd's algorithm picks k distinct positions with exactly k random numbers.
+	picked := make(map[int]struct{}, k)
+	positions := make([]int, 0, k)
+	for j := t.count - k; j < t.count; j++ {
+		pos := intn(j + 1)
+		if _, ok := picked[pos]; ok {
+			pos = j
+		}
+		picked[pos] = struct{}{}
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+	res := make([]T, k)
+	for i, pos := range positions {
+		res[i], _ = t.At(pos)
+	}
+	return res
+}
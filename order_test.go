@@ -0,0 +1,225 @@
+package ibtree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRank(t *testing.T) {
+	tree := New[int](il)
+	for _, v := range rand.Perm(500) {
+		tree = tree.Insert(v * 2)
+	}
+	for i := -1; i < 1002; i++ {
+		pos, found := tree.Rank(tree.Cmp(i))
+		if found != (i >= 0 && i < 1000 && i%2 == 0) {
+			t.Fatalf("Rank(%d) found %v", i, found)
+		}
+		if expect := (i + 1) / 2; i >= 0 && pos != expect && i < 1000 {
+			t.Fatalf("Rank(%d): expected %d, got %d", i, expect, pos)
+		}
+	}
+	if pos, _ := tree.Rank(tree.Cmp(5000)); pos != 500 {
+		t.Fatalf("Expected 500 for an item past the end, got %d", pos)
+	}
+	stable := NewWith[ovr](ol, StableTies()).Insert(ovr{1, 0}, ovr{2, 1}, ovr{2, 2}, ovr{2, 3}, ovr{3, 4})
+	if pos, found := stable.Rank(stable.Cmp(ovr{i: 2})); !found || pos != 1 {
+		t.Fatalf("Expected the first equal item at 1, got %d", pos)
+	}
+	if pos, _ := stable.Rank(stable.Cmp(ovr{i: 3})); pos != 4 {
+		t.Fatalf("Expected 4, got %d", pos)
+	}
+}
+
+func TestAt(t *testing.T) {
+	tree := New[int](il, rand.Perm(700)...)
+	for i := 0; i < 700; i++ {
+		if v, found := tree.At(i); !found || v != i {
+			t.Fatalf("At(%d): got %d %v", i, v, found)
+		}
+		if pos, _ := tree.Rank(tree.Cmp(i)); pos != i {
+			t.Fatalf("Rank and At disagree at %d", i)
+		}
+	}
+	for _, i := range []int{-1, 700, 10000} {
+		if _, found := tree.At(i); found {
+			t.Fatalf("At(%d) should be out of range", i)
+		}
+	}
+}
+
+func TestSubtreeSizes(t *testing.T) {
+	src := rand.New(rand.NewSource(16))
+	for _, opts := range [][]Option{nil, {StableTies()}, {Balancing(RelaxedAVL(2))}, {Balancing(Scapegoat(1))}} {
+		tree := NewWith[int](il, opts...)
+		versions := []*Tree[int]{tree}
+		for i := 0; i < 2000; i++ {
+			base := versions[src.Intn(len(versions))]
+			if src.Intn(3) == 0 {
+				base, _, _ = base.Delete(src.Intn(300))
+			} else {
+				base = base.Insert(src.Intn(300))
+			}
+			versions = append(versions, base)
+		}
+		for _, v := range versions {
+			v.root.balancedWithin(t, 8)
+			if countNodes(v.root) != v.Len() {
+				t.Fatalf("Root size %d does not match Len %d", countNodes(v.root), v.Len())
+			}
+		}
+	}
+}
+
+func TestCountRange(t *testing.T) {
+	tree := New[int](il, rand.Perm(300)...)
+	src := rand.New(rand.NewSource(17))
+	makers := []TestMaker[int]{Lt[int], Lte[int], Gt[int], Gte[int]}
+	for i := 0; i < 500; i++ {
+		var start, stop Test[int]
+		if i%5 != 0 {
+			start = makers[src.Intn(2)](tree.Cmp(src.Intn(320) - 10))
+		}
+		if i%7 != 0 {
+			stop = makers[2+src.Intn(2)](tree.Cmp(src.Intn(320) - 10))
+		}
+		expect := 0
+		tree.Range(start, stop, func(int) bool {
+			expect++
+			return true
+		})
+		if got := tree.CountRange(start, stop); got != expect {
+			t.Fatalf("Expected %d items, got %d", expect, got)
+		}
+	}
+	if New[int](il).CountRange(nil, nil) != 0 {
+		t.Fatalf("Expected an empty Tree to have nothing in range")
+	}
+}
+
+func TestDeleteAt(t *testing.T) {
+	tree := New[int](il, rand.Perm(200)...)
+	orig := tree
+	expect := make([]int, 200)
+	for i := range expect {
+		expect[i] = i
+	}
+	src := rand.New(rand.NewSource(18))
+	for len(expect) > 0 {
+		idx := src.Intn(len(expect))
+		res, deleted, found := tree.DeleteAt(idx)
+		if !found || deleted != expect[idx] {
+			t.Fatalf("DeleteAt(%d): expected %d, got %d %v", idx, expect[idx], deleted, found)
+		}
+		expect = append(expect[:idx], expect[idx+1:]...)
+		checkTree(t, res, expect)
+		tree = res
+	}
+	if res, _, found := tree.DeleteAt(0); found || res != tree {
+		t.Fatalf("Deleting from an empty Tree should fail")
+	}
+	if orig.Len() != 200 {
+		t.Fatalf("DeleteAt modified the original Tree")
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	tree := New[int](il)
+	if _, found := tree.Median(); found {
+		t.Fatalf("Empty Tree should not have a median")
+	}
+	tree = tree.Insert(rand.Perm(101)...)
+	for q, expect := range map[float64]int{0: 0, 0.5: 50, 0.95: 95, 0.999: 99, 1: 100} {
+		if item, found := tree.Quantile(q); !found || item != expect {
+			t.Errorf("Quantile(%v): expected %d, got %d %v", q, expect, item, found)
+		}
+	}
+	for _, q := range []float64{-0.1, 1.1, math.NaN()} {
+		if _, found := tree.Quantile(q); found {
+			t.Errorf("Quantile(%v) should fail", q)
+		}
+	}
+	if item, _ := tree.Median(); item != 50 {
+		t.Errorf("Expected median 50, got %d", item)
+	}
+	if item, _ := tree.Insert(101).Median(); item != 50 {
+		t.Errorf("Expected lower median 50, got %d", item)
+	}
+}
+
+func TestSample(t *testing.T) {
+	tree := New[int](il)
+	for i := 0; i < 50; i++ {
+		tree = tree.Insert(i)
+	}
+	rng := rand.New(rand.NewSource(65))
+	hits := make([]int, 50)
+	for round := 0; round < 2000; round++ {
+		got := tree.Sample(5, rng)
+		if len(got) != 5 {
+			t.Fatalf("Expected 5 items, got %d", len(got))
+		}
+		for i, v := range got {
+			if i > 0 && got[i-1] >= v {
+				t.Fatalf("Sample not ascending and distinct: %v", got)
+			}
+			hits[v]++
+		}
+	}
+	// Every item should be picked about 200 times.
+	for v, n := range hits {
+		if n < 120 || n > 280 {
+			t.Fatalf("Item %d picked %d times out of an expected 200", v, n)
+		}
+	}
+	if got := tree.Sample(80, nil); len(got) != 50 {
+		t.Fatalf("Oversized sample returned %d items", len(got))
+	}
+	if got := tree.Sample(0, rng); len(got) != 0 {
+		t.Fatalf("Empty sample returned %d items", len(got))
+	}
+}
+
+// The benchmarks below compare what keeping subtree sizes costs on writes with what it
+// saves on lookups by position.
+
+func benchTree() *Tree[int] {
+	return CreateWith[int](il, func(f func(int)) {
+		for i := 0; i < 1<<16; i++ {
+			f(i * 2)
+		}
+	})
+}
+
+func BenchmarkInsertDeleteOne(b *testing.B) {
+	tree := benchTree()
+	rs := rand.New(rand.NewSource(16))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := rs.Intn(1<<17) | 1
+		res := tree.Insert(v)
+		res, _, _ = res.Delete(v)
+	}
+}
+
+func BenchmarkAt(b *testing.B) {
+	tree := benchTree()
+	rs := rand.New(rand.NewSource(16))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.At(rs.Intn(tree.Len()))
+	}
+}
+
+func BenchmarkOffsetAndLimit(b *testing.B) {
+	tree := benchTree()
+	rs := rand.New(rand.NewSource(16))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter := tree.OffsetAndLimit(rs.Intn(tree.Len()), 10)
+		for iter.Next() {
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package ibtree
+
+// Pair is a generic two part composite key, such as (tenant, name).
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// MakePair makes a Pair out of a and b.
+func MakePair[A, B any](a A, b B) Pair[A, B] {
+	return Pair[A, B]{First: a, Second: b}
+}
+
+// LessPair returns a LessThan that orders Pairs lexicographically: first by their First
+// components according to la, and then by their Second components according to lb
+// if their First components are equal.
+func LessPair[A, B any](la LessThan[A], lb LessThan[B]) LessThan[Pair[A, B]] {
+	return func(x, y Pair[A, B]) bool {
+		switch {
+		case la(x.First, y.First):
+			return true
+		case la(y.First, x.First):
+			return false
+		default:
+			return lb(x.Second, y.Second)
+		}
+	}
+}
+
+// CmpPair returns a CompareAgainst for reference that orders Pairs the same way
+// LessPair(la, lb) does.
+func CmpPair[A, B any](la LessThan[A], lb LessThan[B], reference Pair[A, B]) CompareAgainst[Pair[A, B]] {
+	return func(treeVal Pair[A, B]) int {
+		switch {
+		case la(treeVal.First, reference.First):
+			return Less
+		case la(reference.First, treeVal.First):
+			return Greater
+		case lb(treeVal.Second, reference.Second):
+			return Less
+		case lb(reference.Second, treeVal.Second):
+			return Greater
+		default:
+			return Equal
+		}
+	}
+}
+
+// CmpFirst returns a CompareAgainst that only looks at the First component of a Pair.
+// Every Pair whose First component is equal to reference compares Equal, which makes it
+// useful with Range and friends to visit all the Pairs that share a First component.
+func CmpFirst[A, B any](la LessThan[A], reference A) CompareAgainst[Pair[A, B]] {
+	return func(treeVal Pair[A, B]) int {
+		switch {
+		case la(treeVal.First, reference):
+			return Less
+		case la(reference, treeVal.First):
+			return Greater
+		default:
+			return Equal
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package ibtree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPair(t *testing.T) {
+	lt := LessPair[string, int](sl, il)
+	tree := New[Pair[string, int]](lt,
+		MakePair("b", 1), MakePair("a", 2), MakePair("a", 1), MakePair("c", 0), MakePair("b", 0), MakePair("a", 1))
+	if tree.Len() != 5 {
+		t.Fatalf("Expected 5 items, got %d", tree.Len())
+	}
+	res := []Pair[string, int]{}
+	tree.Walk(func(p Pair[string, int]) bool {
+		res = append(res, p)
+		return true
+	})
+	expect := []Pair[string, int]{{"a", 1}, {"a", 2}, {"b", 0}, {"b", 1}, {"c", 0}}
+	if !reflect.DeepEqual(expect, res) {
+		t.Fatalf("Expected %v, got %v", expect, res)
+	}
+	for _, p := range expect {
+		if !tree.Has(CmpPair(sl, il, p)) {
+			t.Fatalf("Expected to find %v", p)
+		}
+	}
+	if tree.Has(CmpPair(sl, il, MakePair("b", 2))) {
+		t.Fatalf("Did not expect to find b:2")
+	}
+	res = res[:0]
+	cmp := CmpFirst[string, int](sl, "b")
+	tree.Range(Lt(cmp), Gt(cmp), func(p Pair[string, int]) bool {
+		res = append(res, p)
+		return true
+	})
+	if !reflect.DeepEqual(expect[2:4], res) {
+		t.Fatalf("Expected %v, got %v", expect[2:4], res)
+	}
+}
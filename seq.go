@@ -0,0 +1,57 @@
+package ibtree
+
+import "iter"
+
+// ascend calls yield with the items in the subtree rooted at n that neither start nor stop
+// return true for, in ascending order.  It returns false as soon as yield does.
+func ascend[T any](n *node[T], start, stop Test[T], yield func(T) bool) bool {
+	for n != nil {
+		switch {
+		case start != nil && start(n.i):
+			n = n.r
+		case stop != nil && stop(n.i):
+			n = n.l
+		default:
+			if !ascend(n.l, start, nil, yield) || !yield(n.i) {
+				return false
+			}
+			start = nil
+			n = n.r
+		}
+	}
+	return true
+}
+
+// descend calls yield with every item in the subtree rooted at n in descending order.
+// It returns false as soon as yield does.
+func descend[T any](n *node[T], yield func(T) bool) bool {
+	for n != nil {
+		if !descend(n.r, yield) || !yield(n.i) {
+			return false
+		}
+		n = n.l
+	}
+	return true
+}
+
+// Values returns an iter.Seq that yields the items in the Tree between start and stop
+// in ascending order, for use with range:
+//
+//	for v := range tree.Values(nil, nil) {
+//	    fmt.Println(v)
+//	}
+//
+// start and stop work the same way they do for Iterator, and either may be nil.
+// All still returns an Iter, so Values(nil, nil) is the way to range over the whole Tree.
+func (t *Tree[T]) Values(start, stop Test[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		ascend(t.root, start, stop, yield)
+	}
+}
+
+// Backward returns an iter.Seq that yields every item in the Tree in descending order.
+func (t *Tree[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		descend(t.root, yield)
+	}
+}
@@ -0,0 +1,39 @@
+package ibtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSeq(t *testing.T) {
+	tree := New[int](il, rand.Perm(200)...)
+	n := 0
+	for v := range tree.Values(nil, nil) {
+		if v != n {
+			t.Fatalf("Expected %d, got %d", n, v)
+		}
+		n++
+	}
+	if n != 200 {
+		t.Fatalf("Expected 200 items, got %d", n)
+	}
+	for v := range tree.Backward() {
+		n--
+		if v != n {
+			t.Fatalf("Expected %d, got %d", n, v)
+		}
+	}
+	res := []int{}
+	for v := range tree.Values(Lt(tree.Cmp(50)), Gte(tree.Cmp(60))) {
+		res = append(res, v)
+		if v == 55 {
+			break
+		}
+	}
+	if len(res) != 6 || res[0] != 50 || res[5] != 55 {
+		t.Fatalf("Unexpected bounded range %v", res)
+	}
+	for range New[int](il).Values(nil, nil) {
+		t.Fatalf("Empty Tree yielded an item")
+	}
+}
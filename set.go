@@ -0,0 +1,81 @@
+package ibtree
+
+// Set is an immutable ordered set of items backed by a Tree.  Every method
+// that changes the contents of a Set returns a new Set, and leaves the
+// Set it was called on unchanged.  Sets derived from one another share
+// as much of their underlying storage as possible.
+//
+// Sets combined with Union, Intersect, or Difference must have been created
+// with equivalent LessThan functions.
+type Set[T any] struct {
+	t *Tree[T]
+}
+
+// NewSet allocates a new Set ordered by lt that holds items.
+func NewSet[T any](lt LessThan[T], items ...T) *Set[T] {
+	return &Set[T]{t: New[T](lt, items...)}
+}
+
+// Tree returns the Tree backing the Set.
+func (s *Set[T]) Tree() *Tree[T] {
+	return s.t
+}
+
+// Len returns the number of items in the Set.
+func (s *Set[T]) Len() int {
+	return s.t.Len()
+}
+
+// Has returns whether item is in the Set.
+func (s *Set[T]) Has(item T) bool {
+	return s.t.HasItem(item)
+}
+
+// Contains is the same as Has.
+func (s *Set[T]) Contains(item T) bool {
+	return s.t.HasItem(item)
+}
+
+// Add returns a new Set that holds the items in s along with items.
+// Items already in s are replaced by equal ones from items.
+func (s *Set[T]) Add(items ...T) *Set[T] {
+	return &Set[T]{t: s.t.Insert(items...)}
+}
+
+// Remove returns a new Set that holds the items in s that are not in items.
+func (s *Set[T]) Remove(items ...T) *Set[T] {
+	res, _ := s.t.DeleteItems(items...)
+	return &Set[T]{t: res}
+}
+
+// Union returns a new Set holding every item that is in either s or other.
+// If an item is in both, the one from other is kept.
+func (s *Set[T]) Union(other *Set[T]) *Set[T] {
+	return &Set[T]{t: s.t.Union(other.t)}
+}
+
+// Intersect returns a new Set holding the items in s that are also in other.
+func (s *Set[T]) Intersect(other *Set[T]) *Set[T] {
+	return &Set[T]{t: s.t.Intersection(other.t)}
+}
+
+// Difference returns a new Set holding the items in s that are not in other.
+func (s *Set[T]) Difference(other *Set[T]) *Set[T] {
+	return &Set[T]{t: s.t.Difference(other.t)}
+}
+
+// Subtract is the same as Difference.
+func (s *Set[T]) Subtract(other *Set[T]) *Set[T] {
+	return s.Difference(other)
+}
+
+// Each calls fn with every item in the Set in ascending order,
+// stopping early if fn returns false.
+func (s *Set[T]) Each(fn Test[T]) {
+	s.t.Walk(fn)
+}
+
+// All returns an Iter over every item in the Set in ascending order.
+func (s *Set[T]) All() Iter[T] {
+	return s.t.All()
+}
@@ -0,0 +1,265 @@
+package ibtree
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func setItems(s *Set[int]) (res []int) {
+	s.Each(func(i int) bool {
+		res = append(res, i)
+		return true
+	})
+	return
+}
+
+func checkSet(t *testing.T, s *Set[int], expect map[int]bool) {
+	t.Helper()
+	s.Tree().root.balanced(t)
+	if s.Len() != len(expect) {
+		t.Fatalf("Expected %d items, got %d", len(expect), s.Len())
+	}
+	prev := -1
+	for _, i := range setItems(s) {
+		if !expect[i] || i <= prev {
+			t.Fatalf("Unexpected item %d", i)
+		}
+		prev = i
+	}
+}
+
+func TestSet(t *testing.T) {
+	src := rand.New(rand.NewSource(9))
+	base := NewSet[int](il, src.Perm(2000)[:1000]...)
+	a, b := base, base
+	for i := 0; i < 300; i++ {
+		a = a.Add(src.Intn(3000)).Remove(src.Intn(2000))
+		b = b.Add(src.Intn(3000)).Remove(src.Intn(2000))
+	}
+	for _, other := range []*Set[int]{b, NewSet[int](il, src.Perm(3000)[:500]...), NewSet[int](il), a} {
+		union, inter, diff := map[int]bool{}, map[int]bool{}, map[int]bool{}
+		for _, i := range setItems(a) {
+			union[i] = true
+			if other.Has(i) {
+				inter[i] = true
+			} else {
+				diff[i] = true
+			}
+		}
+		for _, i := range setItems(other) {
+			union[i] = true
+		}
+		checkSet(t, a.Union(other), union)
+		checkSet(t, other.Union(a), union)
+		checkSet(t, a.Intersect(other), inter)
+		checkSet(t, a.Difference(other), diff)
+	}
+	if a.Difference(a).Len() != 0 || a.Intersect(a).Tree().root != a.Tree().root {
+		t.Fatalf("Combining a Set with itself went wrong")
+	}
+	marked := NewSet[ovr](ol, ovr{1, 0}, ovr{2, 0}).Union(NewSet[ovr](ol, ovr{2, 1}))
+	if v, _ := marked.Tree().Fetch(ovr{i: 2}); v.mark != 1 {
+		t.Fatalf("Union should keep items from other")
+	}
+	before := setItems(base)
+	base.Add(5000).Remove(before[0])
+	if len(setItems(base)) != len(before) || !base.Has(before[0]) || base.Has(5000) {
+		t.Fatalf("Set was modified in place")
+	}
+}
+
+func TestTreeUnion(t *testing.T) {
+	src := rand.New(rand.NewSource(21))
+	base := New[ovr](ol)
+	for i := 0; i < 500; i++ {
+		base = base.Insert(ovr{src.Intn(1000), 0})
+	}
+	a, b := base, base
+	for i := 1; i < 200; i++ {
+		a = a.Insert(ovr{src.Intn(1000), i})
+		b = b.Insert(ovr{src.Intn(1000), -i})
+		a, _, _ = a.Delete(ovr{i: src.Intn(1000)})
+	}
+	expect := a.Fork().InsertFrom(b.All())
+	res := a.Union(b)
+	res.root.balanced(t)
+	if res.Len() != expect.Len() {
+		t.Fatalf("Expected %d items, got %d", expect.Len(), res.Len())
+	}
+	x, y := res.All(), expect.All()
+	for x.Next() && y.Next() {
+		if x.Item() != y.Item() {
+			t.Fatalf("Expected %v, got %v", y.Item(), x.Item())
+		}
+	}
+	if a.Union(a).root != a.root || a.Union(New[ovr](ol)).root != a.root {
+		t.Fatalf("Union should reuse the whole Tree when nothing changes")
+	}
+	stable := NewWith[ovr](ol, StableTies()).Insert(ovr{1, 0}, ovr{2, 1}, ovr{2, 2})
+	both := stable.Union(stable.Insert(ovr{2, 3}))
+	both.root.balanced(t)
+	marks := []int{}
+	both.Walk(func(v ovr) bool {
+		marks = append(marks, v.mark)
+		return true
+	})
+	if fmt.Sprint(marks) != "[0 0 1 2 1 2 3]" {
+		t.Fatalf("Unexpected stable union order %v", marks)
+	}
+}
+
+func TestTreeIntersection(t *testing.T) {
+	src := rand.New(rand.NewSource(22))
+	a, b := New[ovr](ol), New[ovr](ol)
+	for i := 0; i < 1000; i++ {
+		a = a.Insert(ovr{src.Intn(2000), 1})
+		b = b.Insert(ovr{src.Intn(2000), 2})
+	}
+	res := a.Intersection(b)
+	res.root.balanced(t)
+	count := 0
+	a.Walk(func(v ovr) bool {
+		got, found := res.Fetch(v)
+		if b.HasItem(v) {
+			count++
+			if !found || got.mark != 1 {
+				t.Fatalf("Expected %v from the receiver in the intersection", v)
+			}
+		} else if found {
+			t.Fatalf("%v should not be in the intersection", v)
+		}
+		return true
+	})
+	if res.Len() != count {
+		t.Fatalf("Expected %d items, got %d", count, res.Len())
+	}
+	if a.Intersection(a).root != a.root || a.Intersection(New[ovr](ol)).Len() != 0 {
+		t.Fatalf("Intersection with itself or an empty Tree went wrong")
+	}
+	stable := NewWith[ovr](ol, StableTies()).Insert(ovr{1, 0}, ovr{2, 1}, ovr{2, 2}, ovr{2, 3}, ovr{3, 4})
+	res = stable.Intersection(NewWith[ovr](ol, StableTies()).Insert(ovr{2, 9}, ovr{3, 9}))
+	res.root.balanced(t)
+	marks := []int{}
+	res.Walk(func(v ovr) bool {
+		marks = append(marks, v.mark)
+		return true
+	})
+	if fmt.Sprint(marks) != "[1 2 3 4]" {
+		t.Fatalf("Unexpected stable intersection %v", marks)
+	}
+}
+
+func TestTreeDifference(t *testing.T) {
+	src := rand.New(rand.NewSource(23))
+	base := New[int](il, src.Perm(3000)[:1500]...)
+	snap := base
+	for i := 0; i < 100; i++ {
+		base, _, _ = base.Delete(src.Intn(3000))
+		base = base.Insert(src.Intn(3000))
+	}
+	removed := snap.Difference(base)
+	added := base.Difference(snap)
+	removed.root.balanced(t)
+	added.root.balanced(t)
+	check := func(res, from, without *Tree[int]) {
+		t.Helper()
+		count := 0
+		from.Walk(func(v int) bool {
+			if without.HasItem(v) == res.HasItem(v) {
+				t.Fatalf("%d is wrong in the difference", v)
+			}
+			if res.HasItem(v) {
+				count++
+			}
+			return true
+		})
+		if count != res.Len() {
+			t.Fatalf("Expected %d items, got %d", count, res.Len())
+		}
+	}
+	check(removed, snap, base)
+	check(added, base, snap)
+	if base.Difference(base).Len() != 0 || base.Difference(New[int](il)).root != base.root {
+		t.Fatalf("Difference with itself or an empty Tree went wrong")
+	}
+	stable := NewWith[ovr](ol, StableTies()).Insert(ovr{1, 0}, ovr{2, 1}, ovr{2, 2}, ovr{2, 3}, ovr{3, 4})
+	res := stable.Difference(NewWith[ovr](ol, StableTies()).Insert(ovr{2, 9}))
+	res.root.balanced(t)
+	if res.Len() != 2 || res.HasItem(ovr{i: 2}) {
+		t.Fatalf("Expected every item equal to 2 to be gone")
+	}
+}
+
+func TestTreeSymmetricDifference(t *testing.T) {
+	src := rand.New(rand.NewSource(24))
+	a := New[int](il, src.Perm(3000)[:1500]...)
+	b := a
+	for i := 0; i < 200; i++ {
+		a, _, _ = a.Delete(src.Intn(3000))
+		b = b.Insert(src.Intn(3000))
+	}
+	for _, other := range []*Tree[int]{b, New[int](il, src.Perm(3000)[:700]...), New[int](il), a} {
+		res := a.SymmetricDifference(other)
+		res.root.balanced(t)
+		expect := a.Difference(other).Union(other.Difference(a))
+		if res.Len() != expect.Len() {
+			t.Fatalf("Expected %d items, got %d", expect.Len(), res.Len())
+		}
+		expect.Walk(func(v int) bool {
+			if !res.HasItem(v) {
+				t.Fatalf("Missing %d", v)
+			}
+			return true
+		})
+	}
+	stable := NewWith[ovr](ol, StableTies()).Insert(ovr{1, 0}, ovr{2, 1}, ovr{2, 2})
+	res := stable.SymmetricDifference(NewWith[ovr](ol, StableTies()).Insert(ovr{2, 9}, ovr{3, 9}))
+	if res.Len() != 2 || res.HasItem(ovr{i: 2}) {
+		t.Fatalf("Unexpected stable symmetric difference")
+	}
+}
+
+func TestTreeMergeWith(t *testing.T) {
+	a := New[ovr](ol, ovr{1, 1}, ovr{2, 1}, ovr{3, 1})
+	b := New[ovr](ol, ovr{2, 10}, ovr{3, 10}, ovr{4, 10})
+	calls := 0
+	res := a.MergeWith(b, func(mine, theirs ovr) ovr {
+		calls++
+		return ovr{mine.i, mine.mark + theirs.mark}
+	})
+	res.root.balanced(t)
+	marks := []int{}
+	res.Walk(func(v ovr) bool {
+		marks = append(marks, v.mark)
+		return true
+	})
+	if calls != 2 || fmt.Sprint(marks) != "[1 11 11 10]" {
+		t.Fatalf("Unexpected merge %v after %d calls", marks, calls)
+	}
+	if a.MergeWith(a, func(mine, theirs ovr) ovr {
+		t.Fatalf("resolve called on a shared subtree")
+		return mine
+	}).root != a.root {
+		t.Fatalf("Merging a Tree with itself should share everything")
+	}
+	if v, _ := a.MergeWith(b, nil).Fetch(ovr{i: 2}); v.mark != 10 {
+		t.Fatalf("Expected the item from other with a nil resolve")
+	}
+}
+
+func TestSetFacade(t *testing.T) {
+	s := NewSet[int](il, 1, 2, 3, 4)
+	if !s.Contains(3) || s.Contains(5) {
+		t.Fatalf("Contains failed")
+	}
+	rest := s.Subtract(NewSet[int](il, 2, 4))
+	iter := rest.All()
+	res := []int{}
+	for iter.Next() {
+		res = append(res, iter.Item())
+	}
+	if fmt.Sprint(res) != "[1 3]" {
+		t.Fatalf("Expected [1 3], got %v", res)
+	}
+}
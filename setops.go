@@ -0,0 +1,170 @@
+package ibtree
+
+// The functions in this file implement set algebra directly on subtrees by splitting
+// one subtree around the root of the other and joining the results back together.
+// Subtrees that are shared between the inputs are recognized by pointer and never
+// descended into, so combining two Trees forked from a common ancestor only does work
+// proportional to how far they have drifted apart.  The results share every subtree
+// they can with the inputs.  Both subtrees must be ordered by t.less.
+
+// union returns a subtree holding every item in a or b.  If an item is in both,
+// resolve is called to pick the item to keep, or the one from b is kept if resolve is nil.
+// If t keeps equal items, both are kept instead.
+func (t *Tree[T]) union(ns *nodeStack[T], a, b *node[T], resolve func(mine, theirs T) T) *node[T] {
+	if a == nil || (a == b && !t.opts.stable) {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	var l, eq, r *node[T]
+	if t.opts.stable {
+		// Keep both, with the items from a before the equal ones from b.
+		l, r, _ = ns.splitBy(a, func(item T) bool { return !t.less(b.i, item) })
+	} else {
+		l, eq, r = ns.split(a, t.Cmp(b.i))
+	}
+	ul, ur := t.union(ns, l, b.l, resolve), t.union(ns, r, b.r, resolve)
+	if eq != nil && resolve != nil {
+		return ns.join(ul, resolve(eq.i, b.i), ur)
+	}
+	if ul == b.l && ur == b.r {
+		return b
+	}
+	return ns.join(ul, b.i, ur)
+}
+
+// intersect returns a subtree holding the items in a that are also in b.
+func (t *Tree[T]) intersect(ns *nodeStack[T], a, b *node[T]) *node[T] {
+	if a == nil || b == nil {
+		return nil
+	}
+	if a == b {
+		return a
+	}
+	l, eq, r := ns.split(b, t.Cmp(a.i))
+	if eq != nil && t.opts.stable {
+		// Items equal to a.i may be on either side of it in a, so both sides need to see eq.
+		l, r = ns.join(l, eq.i, nil), ns.join(nil, eq.i, r)
+	}
+	il, ir := t.intersect(ns, a.l, l), t.intersect(ns, a.r, r)
+	switch {
+	case eq == nil:
+		return ns.join2(il, ir)
+	case il == a.l && ir == a.r:
+		return a
+	default:
+		return ns.join(il, a.i, ir)
+	}
+}
+
+// difference returns a subtree holding the items in a that are not in b.
+func (t *Tree[T]) difference(ns *nodeStack[T], a, b *node[T]) *node[T] {
+	if a == nil || a == b {
+		return nil
+	}
+	if b == nil {
+		return a
+	}
+	l, eq, r := ns.split(b, t.Cmp(a.i))
+	if eq != nil && t.opts.stable {
+		l, r = ns.join(l, eq.i, nil), ns.join(nil, eq.i, r)
+	}
+	dl, dr := t.difference(ns, a.l, l), t.difference(ns, a.r, r)
+	switch {
+	case eq != nil:
+		return ns.join2(dl, dr)
+	case dl == a.l && dr == a.r:
+		return a
+	default:
+		return ns.join(dl, a.i, dr)
+	}
+}
+
+// symmetricDifference returns a subtree holding the items that are in exactly one of a and b.
+// t must not keep equal items.
+func (t *Tree[T]) symmetricDifference(ns *nodeStack[T], a, b *node[T]) *node[T] {
+	if a == b {
+		return nil
+	}
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	l, eq, r := ns.split(b, t.Cmp(a.i))
+	sl, sr := t.symmetricDifference(ns, a.l, l), t.symmetricDifference(ns, a.r, r)
+	switch {
+	case eq != nil:
+		return ns.join2(sl, sr)
+	case sl == a.l && sr == a.r:
+		return a
+	default:
+		return ns.join(sl, a.i, sr)
+	}
+}
+
+// combine makes a new Tree out of t and other using op.
+func (t *Tree[T]) combine(other *Tree[T], op func(*nodeStack[T], *node[T], *node[T]) *node[T]) *Tree[T] {
+	gen := t.nextGen(other)
+	ns := t.joiner(gen)
+	defer t.putNsp(ns)
+	root := op(ns, t.root, other.root)
+	return t.derive(root, countNodes(root), gen)
+}
+
+// Union returns a new Tree holding every item in t or other, which must be ordered the
+// same way as t.  If an item is in both, the one from other is kept, just as if all the
+// items in other had been inserted into t.  If t was created with StableTies, every item
+// from both Trees is kept, and items from other come after the equal ones from t.
+//
+// Instead of inserting items one at a time, Union splits and joins whole subtrees, and
+// skips subtrees that t and other share.  The new Tree shares nodes with both t and other.
+func (t *Tree[T]) Union(other *Tree[T]) *Tree[T] {
+	return t.MergeWith(other, nil)
+}
+
+// MergeWith is Union, except that when an item is in both t and other, resolve is called
+// with the item from t and the item from other, and the item it returns is kept instead.
+// The returned item must be equal to the ones passed in.  Subtrees that t and other share
+// hold the same items, so they are kept as they are without calling resolve.  If resolve
+// is nil, the item from other is kept.  If t was created with StableTies, resolve is never called.
+func (t *Tree[T]) MergeWith(other *Tree[T], resolve func(mine, theirs T) T) *Tree[T] {
+	return t.combine(other, func(ns *nodeStack[T], a, b *node[T]) *node[T] {
+		return t.union(ns, a, b, resolve)
+	})
+}
+
+// Intersection returns a new Tree holding the items in t that are also in other, which
+// must be ordered the same way as t.  The items in the new Tree come from t.  If t was
+// created with StableTies, every item in t that is equal to at least one item in other is kept.
+//
+// Intersection splits other around the items in t instead of looking them up one at a time,
+// and skips subtrees that t and other share.  The new Tree shares nodes with t.
+func (t *Tree[T]) Intersection(other *Tree[T]) *Tree[T] {
+	return t.combine(other, t.intersect)
+}
+
+// Difference returns a new Tree holding the items in t that are not in other, which
+// must be ordered the same way as t.  If t was created with StableTies, every item in t
+// that is equal to an item in other is left out.
+//
+// Like Intersection, Difference works on whole subtrees and skips subtrees that t and
+// other share, which makes it cheap to find what was removed between two versions of a Tree.
+// The new Tree shares nodes with t.
+func (t *Tree[T]) Difference(other *Tree[T]) *Tree[T] {
+	return t.combine(other, t.difference)
+}
+
+// SymmetricDifference returns a new Tree holding the items that are in exactly one of t
+// and other, which must be ordered the same way as t.  It takes a single pass over both
+// Trees, skipping the subtrees they share, and the new Tree shares nodes with both of them.
+// If t was created with StableTies, every item in either Tree that is equal to an item in
+// the other one is left out.
+func (t *Tree[T]) SymmetricDifference(other *Tree[T]) *Tree[T] {
+	if t.opts.stable {
+		return t.Difference(other).Union(other.Difference(t))
+	}
+	return t.combine(other, t.symmetricDifference)
+}
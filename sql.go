@@ -0,0 +1,24 @@
+package ibtree
+
+import (
+	"database/sql"
+	"io"
+)
+
+// FromRows builds a new Tree out of a query result.  scan is called once per row
+// to turn it into an item.  FromRows closes rows when it is done with them, and returns
+// the first error from scan or rows.Err.
+//
+// Results from queries with an ORDER BY that matches lt will be loaded without any rebalancing.
+func FromRows[T any](lt LessThan[T], rows *sql.Rows, scan func(*sql.Rows) (T, error)) (*Tree[T], error) {
+	defer rows.Close()
+	return Import(lt, func() (item T, err error) {
+		if !rows.Next() {
+			if err = rows.Err(); err == nil {
+				err = io.EOF
+			}
+			return
+		}
+		return scan(rows)
+	})
+}
@@ -0,0 +1,71 @@
+package ibtree
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+)
+
+// fakeDriver serves every query with the rows in fakeRows.
+type fakeDriver struct{}
+type fakeConn struct{}
+type fakeStmt struct{}
+type fakeRows struct{ at int }
+
+var fakeData = [][]driver.Value{{"b", int64(2)}, {"a", int64(1)}, {"c", int64(3)}}
+
+func (fakeDriver) Open(string) (driver.Conn, error)         { return fakeConn{}, nil }
+func (fakeConn) Prepare(string) (driver.Stmt, error)        { return fakeStmt{}, nil }
+func (fakeConn) Close() error                               { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                  { return nil, errors.New("no transactions") }
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return 0 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return nil, errors.New("no exec") }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return &fakeRows{}, nil }
+func (r *fakeRows) Columns() []string                       { return []string{"key", "val"} }
+func (r *fakeRows) Close() error                            { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.at == len(fakeData) {
+		return io.EOF
+	}
+	copy(dest, fakeData[r.at])
+	r.at++
+	return nil
+}
+
+func init() {
+	sql.Register("ibtree-fake", fakeDriver{})
+}
+
+func TestFromRows(t *testing.T) {
+	db, err := sql.Open("ibtree-fake", "")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	defer db.Close()
+	scan := func(rows *sql.Rows) (res kv, err error) {
+		err = rows.Scan(&res.Key, &res.Val)
+		return
+	}
+	rows, err := db.Query("select key, val from kv")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	tree, err := FromRows[kv](kvl, rows, scan)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if tree.Len() != 3 {
+		t.Fatalf("Expected 3 items, got %d", tree.Len())
+	}
+	if v, _ := tree.Min(); v.Key != "a" || v.Val != 1 {
+		t.Fatalf("Expected a:1, got %v", v)
+	}
+	rows, _ = db.Query("select key, val from kv")
+	boom := errors.New("boom")
+	if _, err = FromRows[kv](kvl, rows, func(*sql.Rows) (kv, error) { return kv{}, boom }); err != boom {
+		t.Fatalf("Expected boom, got %v", err)
+	}
+}
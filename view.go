@@ -0,0 +1,127 @@
+package ibtree
+
+// View is a read-only window onto the items of a Tree that lie between two bounds.  Views
+// do not copy anything, and since Trees never change, a View keeps seeing the same items
+// for as long as it exists.  The bounds work the same way the start and stop tests passed
+// to Iterator do, and a nil bound leaves that end of the View open.
+type View[T any] struct {
+	t           *Tree[T]
+	start, stop Test[T]
+}
+
+// Head returns a View of the items in the Tree that stop returns false for.
+//
+// Gte stop == items less than the reference, Gt stop == items less than or equal to it
+func (t *Tree[T]) Head(stop Test[T]) *View[T] {
+	return &View[T]{t: t, stop: stop}
+}
+
+// Tail returns a View of the items in the Tree that start returns false for.
+//
+// Lt start == items greater than or equal to the reference, Lte start == items greater than it
+func (t *Tree[T]) Tail(start Test[T]) *View[T] {
+	return &View[T]{t: t, start: start}
+}
+
+// View returns a View of the items in the Tree between start and stop.
+func (t *Tree[T]) View(start, stop Test[T]) *View[T] {
+	return &View[T]{t: t, start: start, stop: stop}
+}
+
+// either returns a Test that is true when a or b is, ignoring nil Tests.
+func either[T any](a, b Test[T]) Test[T] {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	return func(item T) bool { return a(item) || b(item) }
+}
+
+// Tree returns the Tree the View looks at.
+func (v *View[T]) Tree() *Tree[T] {
+	return v.t
+}
+
+// Head narrows the View to the items in it that stop returns false for.
+func (v *View[T]) Head(stop Test[T]) *View[T] {
+	return &View[T]{t: v.t, start: v.start, stop: either(v.stop, stop)}
+}
+
+// Tail narrows the View to the items in it that start returns false for.
+func (v *View[T]) Tail(start Test[T]) *View[T] {
+	return &View[T]{t: v.t, start: either(v.start, start), stop: v.stop}
+}
+
+// contains returns whether item lies between the bounds of the View.
+func (v *View[T]) contains(item T) bool {
+	return (v.start == nil || !v.start(item)) && (v.stop == nil || !v.stop(item))
+}
+
+// Len returns the number of items in the View.  It runs in O(log n) time.
+func (v *View[T]) Len() int {
+	return v.t.CountRange(v.start, v.stop)
+}
+
+// Get is Tree.Get restricted to the items in the View.
+func (v *View[T]) Get(cmp CompareAgainst[T]) (item T, found bool) {
+	if item, found = v.t.Get(cmp); found && v.contains(item) {
+		return
+	}
+	var ref T
+	return ref, false
+}
+
+// Has returns true if the View contains an item equal to CompareAgainst.
+func (v *View[T]) Has(cmp CompareAgainst[T]) bool {
+	_, found := v.Get(cmp)
+	return found
+}
+
+// Fetch is Tree.Fetch restricted to the items in the View.
+func (v *View[T]) Fetch(item T) (res T, found bool) {
+	if res, found = v.t.Fetch(item); found && v.contains(res) {
+		return
+	}
+	var ref T
+	return ref, false
+}
+
+// Min returns the smallest item in the View and true, or a zero T and false if the View is empty.
+func (v *View[T]) Min() (item T, found bool) {
+	start := v.start
+	if start == nil {
+		start = func(T) bool { return false }
+	}
+	if item, found = firstAfter(v.t.root, start); found && v.contains(item) {
+		return
+	}
+	var ref T
+	return ref, false
+}
+
+// Max returns the largest item in the View and true, or a zero T and false if the View is empty.
+func (v *View[T]) Max() (item T, found bool) {
+	notStop := func(T) bool { return true }
+	if v.stop != nil {
+		notStop = func(item T) bool { return !v.stop(item) }
+	}
+	if item, found = lastWhile(v.t.root, notStop); found && v.contains(item) {
+		return
+	}
+	var ref T
+	return ref, false
+}
+
+// Iterator returns an Iter over the items in the View that are also between start and stop.
+// See Tree.Iterator for how start and stop work.
+func (v *View[T]) Iterator(start, stop Test[T]) Iter[T] {
+	return v.t.Iterator(either(v.start, start), either(v.stop, stop))
+}
+
+// Walk calls iterator once for each item in the View in ascending order.
+// Walk will return early if iterator returns false.
+func (v *View[T]) Walk(iterator Test[T]) {
+	v.t.Range(v.start, v.stop, iterator)
+}
@@ -0,0 +1,67 @@
+package ibtree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestView(t *testing.T) {
+	tree := New[int](il, rand.Perm(100)...)
+	head := tree.Head(Gte(tree.Cmp(40)))
+	tail := tree.Tail(Lte(tree.Cmp(59)))
+	mid := head.Tail(Lt(tree.Cmp(30)))
+	for _, tc := range []struct {
+		name   string
+		v      *View[int]
+		lo, hi int
+	}{
+		{"head", head, 0, 39},
+		{"tail", tail, 60, 99},
+		{"mid", mid, 30, 39},
+		{"empty", tail.Head(Gt(tree.Cmp(10))), 0, -1},
+	} {
+		expect := []int{}
+		for i := tc.lo; i <= tc.hi; i++ {
+			expect = append(expect, i)
+		}
+		if tc.v.Len() != len(expect) {
+			t.Errorf("%s: expected %d items, got %d", tc.name, len(expect), tc.v.Len())
+		}
+		res := []int{}
+		tc.v.Walk(func(i int) bool {
+			res = append(res, i)
+			return true
+		})
+		if !reflect.DeepEqual(res, expect) {
+			t.Errorf("%s: expected %v, got %v", tc.name, expect, res)
+		}
+		for i := -1; i <= 100; i++ {
+			_, found := tc.v.Get(tree.Cmp(i))
+			if found != (i >= tc.lo && i <= tc.hi) || found != tc.v.Has(tree.Cmp(i)) {
+				t.Errorf("%s: wrong result looking up %d", tc.name, i)
+			}
+		}
+		lo, loOK := tc.v.Min()
+		hi, hiOK := tc.v.Max()
+		if len(expect) == 0 {
+			if loOK || hiOK {
+				t.Errorf("%s: empty View should not have a Min or Max", tc.name)
+			}
+		} else if lo != tc.lo || hi != tc.hi || !loOK || !hiOK {
+			t.Errorf("%s: expected Min %d and Max %d, got %d and %d", tc.name, tc.lo, tc.hi, lo, hi)
+		}
+	}
+	iter := mid.Iterator(nil, Gt(tree.Cmp(35)))
+	defer iter.Release()
+	n := 0
+	for iter.Prev() {
+		if iter.Item() != 35-n {
+			t.Fatalf("Expected %d, got %d", 35-n, iter.Item())
+		}
+		n++
+	}
+	if n != 6 {
+		t.Fatalf("Expected 6 items, got %d", n)
+	}
+}